@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"time"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// How long the link sent to a new email address stays valid.
+	emailVerificationTTL = 24 * time.Hour
+	// Signing in with the identity provider again stands in for the current
+	// password for this long, accounts created through it have a password
+	// nobody knows.
+	reauthTimeout = 5 * time.Minute
+)
+
+var (
+	errWrongPassword        = errors.New("current password is incorrect")
+	errInvalidVerification  = errors.New("this link is invalid or has expired")
+	errEmailChangeFailed    = errors.New("could not change your email to this address")
+	errPasswordsDoNotMatch  = errors.New("password do not match")
+	errDeleteNotConfirmed   = errors.New("type DELETE to confirm")
+	errVerificationNotSent  = errors.New("could not send the confirmation email, try again")
+	errEmailAlreadyAssigned = errors.New("this is already your email")
+)
+
+// accountConfirmation is how the account page asks to confirm changes. Accounts
+// linked to the identity provider can sign in with Provider again instead of
+// entering the current password, Reauthenticated is set once they did.
+type accountConfirmation struct {
+	Provider        string
+	Reauthenticated bool
+}
+
+func (a AuthService) checkPassword(user *sqlc.User, password string) error {
+	if bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(password)) != nil {
+		return errWrongPassword
+	}
+
+	return nil
+}
+
+// confirmUser checks the current password posted with a form, unless the
+// user signed in with the identity provider again within reauthTimeout.
+func (a AuthService) confirmUser(c echo.Context, user *sqlc.User) error {
+	if reauthenticated(c, user.ID) {
+		return nil
+	}
+
+	return a.checkPassword(user, c.FormValue("current-password"))
+}
+
+func reauthenticated(c echo.Context, userID int64) bool {
+	sess, _ := session.Get("session", c)
+	reauthUserID, ok := sess.Values["reauth_user_id"].(int64)
+	if !ok || reauthUserID != userID {
+		return false
+	}
+	since, _ := sess.Values["reauth_since"].(int64)
+
+	return time.Since(time.Unix(since, 0)) <= reauthTimeout
+}
+
+func (a AuthService) changePassword(user *sqlc.User, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return a.Db.UpdateUserPassword(a.Ctx, sqlc.UpdateUserPasswordParams{HashedPassword: hashedPassword, ID: user.ID})
+}
+
+// requestEmailChange emails a link to the new address. The account keeps its
+// current email until that link is opened.
+func (a AuthService) requestEmailChange(user *sqlc.User, email string) error {
+	if email == user.Email {
+		return errEmailAlreadyAssigned
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedToken := sha256.Sum256([]byte(token))
+
+	if err := a.Db.DeleteEmailVerifications(a.Ctx, user.ID); err != nil {
+		return err
+	}
+	err = a.Db.CreateEmailVerification(a.Ctx, sqlc.CreateEmailVerificationParams{
+		HashedToken: hashedToken[:],
+		UserID:      user.ID,
+		Email:       email,
+		Expires:     time.Now().UTC().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := a.Email.SendEmailVerification(email, a.BaseURL+"/account/email/verify?token="+token); err != nil {
+		slog.Error("failed to send email verification", "user_id", user.ID, "err", err)
+		return errVerificationNotSent
+	}
+
+	return nil
+}
+
+func (a AuthService) verifyEmailChange(token string) error {
+	hashedToken := sha256.Sum256([]byte(token))
+	verification, err := a.Db.GetEmailVerification(a.Ctx, hashedToken[:])
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalidVerification
+	}
+	if err != nil {
+		return err
+	}
+	if time.Now().After(verification.Expires) {
+		return errInvalidVerification
+	}
+
+	err = a.Db.UpdateUserEmail(a.Ctx, sqlc.UpdateUserEmailParams{Email: verification.Email, ID: verification.UserID})
+	if err != nil {
+		slog.Error("failed to change email", "user_id", verification.UserID, "err", err)
+		return errEmailChangeFailed
+	}
+
+	return a.Db.DeleteEmailVerifications(a.Ctx, verification.UserID)
+}
+
+// deleteAccount removes the user along with everything that belongs to it:
+// tracked names with their notification emails and history, watched
+// characters with their renames, API tokens, two-factor secrets, linked
+// identities and pending email changes. Sessions of the user end with it, as
+// currentUser no longer finds it.
+func (a AuthService) deleteAccount(user *sqlc.User) error {
+	tx, err := a.sqlDB.BeginTx(a.Ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := a.Db.WithTx(tx)
+	steps := []func() error{
+		func() error { return q.DeleteUserFormerNames(a.Ctx, sql.NullInt64{Int64: user.ID, Valid: true}) },
+		func() error { return q.DeleteUserStatusChanges(a.Ctx, user.ID) },
+		func() error { return q.DeleteUserCharacterRenames(a.Ctx, user.ID) },
+		func() error { return q.DeleteUserWatchedCharacters(a.Ctx, user.ID) },
+		func() error { return q.DeleteUserAPITokens(a.Ctx, user.ID) },
+		func() error { return q.DeleteEmailVerifications(a.Ctx, user.ID) },
+		func() error { return q.DeleteRecoveryCodes(a.Ctx, user.ID) },
+		func() error { return q.DeleteUserTOTP(a.Ctx, user.ID) },
+		func() error { return q.DeleteUserIdentities(a.Ctx, user.ID) },
+		func() error { return q.DeleteUser(a.Ctx, user.ID) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (a *AuthService) AccountPage(c echo.Context) error {
+	return a.renderAccountPage(c, "", nil)
+}
+
+func (a *AuthService) ChangePassword(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	password1 := c.FormValue("password1")
+	password2 := c.FormValue("password2")
+	if password1 != password2 {
+		return a.renderAccountPage(c, "", errPasswordsDoNotMatch)
+	}
+
+	if err := a.confirmUser(c, user); err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+	err = a.changePassword(user, password1)
+	if err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+	// Other sessions end with the old password, this one goes on.
+	changed, err := a.Db.GetUserByID(a.Ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	sess, _ := session.Get("session", c)
+	setSessionUser(sess, &changed)
+	sess.Save(c.Request(), c.Response())
+
+	return a.renderAccountPage(c, "Your password has been changed.", nil)
+}
+
+func (a *AuthService) ChangeEmail(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := a.confirmUser(c, user); err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+	email := c.FormValue("email")
+	err = a.requestEmailChange(user, email)
+	if err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+
+	return a.renderAccountPage(c, "We sent a confirmation link to "+email+". Your email changes once you open it.", nil)
+}
+
+func (a *AuthService) VerifyEmail(c echo.Context) error {
+	err := a.verifyEmailChange(c.QueryParam("token"))
+	if errors.Is(err, errInvalidVerification) || errors.Is(err, errEmailChangeFailed) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, "/account")
+}
+
+func (a *AuthService) DeleteAccount(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if c.FormValue("confirm") != "DELETE" {
+		return a.renderAccountPage(c, "", errDeleteNotConfirmed)
+	}
+	if err := a.confirmUser(c, user); err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+	err = a.deleteAccount(user)
+	if err != nil {
+		return a.renderAccountPage(c, "", err)
+	}
+
+	sess, _ := session.Get("session", c)
+	sess.Options.MaxAge = -1
+	sess.Save(c.Request(), c.Response())
+
+	return c.Redirect(http.StatusFound, "/signup")
+}
+
+func (a *AuthService) renderAccountPage(c echo.Context, message string, err error) error {
+	user, userErr := a.currentUser(c)
+	if userErr != nil {
+		return userErr
+	}
+
+	var errorMsg *string
+	if err != nil {
+		if !errors.Is(err, errWrongPassword) && !errors.Is(err, errPasswordsDoNotMatch) &&
+			!errors.Is(err, errDeleteNotConfirmed) && !errors.Is(err, errVerificationNotSent) &&
+			!errors.Is(err, errEmailAlreadyAssigned) && !errors.Is(err, errOIDCReauth) {
+			return err
+		}
+		msg := err.Error()
+		errorMsg = &msg
+	}
+
+	confirm := accountConfirmation{Reauthenticated: reauthenticated(c, user.ID)}
+	if a.OIDC != nil {
+		linked, err := a.Db.CountUserIdentities(a.Ctx, sqlc.CountUserIdentitiesParams{UserID: user.ID, Issuer: a.OIDC.issuer})
+		if err != nil {
+			return err
+		}
+		if linked > 0 {
+			confirm.Provider = a.OIDC.Name
+		}
+	}
+
+	component := layout(accountSettings(user, message, errorMsg, confirm), true)
+	return component.Render(c.Request().Context(), c.Response())
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+func newAccountTestApp(t *testing.T) (*httptest.Server, *AuthService) {
+	t.Helper()
+	db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	auth := NewAuthService(db.Db, nil, "")
+	e := echo.New()
+	e.Use(session.Middleware(sessions.NewCookieStore([]byte("test session secret"))))
+	e.Use(auth.AuthMiddleware)
+	e.POST("/signin", auth.SignIn)
+	e.GET("/account", auth.AccountPage)
+	e.POST("/account/password", auth.ChangePassword)
+	e.POST("/account/delete", auth.DeleteAccount)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	return srv, auth
+}
+
+func signInTestBrowser(t *testing.T, srv *httptest.Server, email, password string) *http.Client {
+	t.Helper()
+	browser := newTestBrowser(t)
+	resp, err := browser.PostForm(srv.URL+"/signin", url.Values{"email": {email}, "password": {password}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/" {
+		t.Fatalf("sign in: status, location = %d, %q, want a redirect to /", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	return browser
+}
+
+// signedIn reports whether browser gets the account page rather than being
+// sent to sign in.
+func signedIn(t *testing.T, srv *httptest.Server, browser *http.Client) bool {
+	t.Helper()
+	resp, err := browser.Get(srv.URL + "/account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+func TestSessionsEndWithAccountChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		form url.Values
+		// signedIn is whether the browser that made the change stays
+		// signed in.
+		signedIn bool
+	}{
+		{"password changed", "/account/password", url.Values{"current-password": {"password"}, "password1": {"new password"}, "password2": {"new password"}}, true},
+		{"account deleted", "/account/delete", url.Values{"current-password": {"password"}, "confirm": {"DELETE"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, auth := newAccountTestApp(t)
+			if _, err := auth.signUp("alice@example.com", "password"); err != nil {
+				t.Fatal(err)
+			}
+			browser := signInTestBrowser(t, srv, "alice@example.com", "password")
+			other := signInTestBrowser(t, srv, "alice@example.com", "password")
+
+			resp, err := browser.PostForm(srv.URL+tt.path, tt.form)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := signedIn(t, srv, browser); got != tt.signedIn {
+				t.Errorf("browser that made the change signed in = %v, want %v", got, tt.signedIn)
+			}
+			if signedIn(t, srv, other) {
+				t.Error("other browser still signed in")
+			}
+		})
+	}
+}
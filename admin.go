@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const recentNotificationsLimit = 50
+
+type AdminConsole struct {
+	Users       []sqlc.ListUsersRow
+	FormerNames []FormerName
+	Poller      PollerState
+	Mirrors     []MirrorStatus
+	// Cache is nil when caching is turned off.
+	Cache         *CacheStats
+	Notifications []sqlc.Notification
+	Message       string
+}
+
+func (a AdminConsole) UserEmail(userID int64) string {
+	for _, user := range a.Users {
+		if user.ID == userID {
+			return user.Email
+		}
+	}
+
+	return ""
+}
+
+type AdminService struct {
+	Ctx     context.Context
+	Db      *repositoryClient
+	Queries *sqlc.Queries
+	Poller  *Poller
+}
+
+func NewAdminService(db *repositoryClient, poller *Poller) *AdminService {
+	return &AdminService{
+		Ctx:     context.Background(),
+		Db:      db,
+		Queries: sqlc.New(db.Db),
+		Poller:  poller,
+	}
+}
+
+// AdminMiddleware only lets admins through. It has to run after
+// AuthMiddleware, which puts the signed in user in the request context.
+func AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := contextUser(c.Request().Context())
+		if user == nil || !user.IsAdmin {
+			return echo.NewHTTPError(http.StatusForbidden, "This page is only available to admins.")
+		}
+
+		return next(c)
+	}
+}
+
+func (s *AdminService) Console(c echo.Context) error {
+	return s.render(c, "")
+}
+
+func (s *AdminService) DisableUser(c echo.Context) error {
+	return s.setUserDisabled(c, true)
+}
+
+func (s *AdminService) EnableUser(c echo.Context) error {
+	return s.setUserDisabled(c, false)
+}
+
+func (s *AdminService) setUserDisabled(c echo.Context, disabled bool) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user id.")
+	}
+	if userID == contextUser(c.Request().Context()).ID {
+		return s.render(c, "You can not disable your own account.")
+	}
+
+	err = s.Queries.SetUserDisabled(s.Ctx, sqlc.SetUserDisabledParams{Disabled: disabled, ID: userID})
+	if err != nil {
+		return err
+	}
+
+	return s.render(c, "")
+}
+
+func (s *AdminService) RecheckName(c echo.Context) error {
+	name := c.Param("name")
+
+	formerNames, err := s.Db.GetFormerNamesByKey(c.Request().Context(), name)
+	if err != nil {
+		return err
+	}
+	if len(formerNames) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Former Name "+name+" not found")
+	}
+	for _, formerName := range formerNames {
+		if err := s.Poller.CheckName(withoutCache(c.Request().Context()), formerName); err != nil {
+			return s.render(c, "Check failed: "+err.Error())
+		}
+	}
+
+	return s.render(c, "Checked "+formerNames[0].Name+".")
+}
+
+func (s *AdminService) RecheckAll(c echo.Context) error {
+	s.Poller.Recheck()
+
+	return s.render(c, "A new pass over all names has been scheduled.")
+}
+
+func (s *AdminService) SendTestEmail(c echo.Context) error {
+	emails := strings.Split(c.FormValue("emails"), ",")
+	formerName := c.FormValue("name")
+	var notifyWhen NotifyWhen
+	notifyWhen = notifyWhen.FromString(c.FormValue("notify-when"))
+
+	s.Poller.notify(c.Request().Context(), emails, formerName, notifyWhen)
+
+	return s.render(c, "Test email sent.")
+}
+
+func (s *AdminService) render(c echo.Context, message string) error {
+	users, err := s.Queries.ListUsers(s.Ctx)
+	if err != nil {
+		return err
+	}
+	formerNames, err := s.Db.GetFormerNames(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	notifications, err := s.Queries.GetRecentNotifications(s.Ctx, recentNotificationsLimit)
+	if err != nil {
+		return err
+	}
+
+	var cacheStats *CacheStats
+	if s.Poller.Api.Cache != nil {
+		stats := s.Poller.Api.Cache.Stats()
+		cacheStats = &stats
+	}
+
+	console := AdminConsole{
+		Users:         users,
+		FormerNames:   formerNames,
+		Poller:        s.Poller.State(),
+		Mirrors:       s.Poller.Api.MirrorStatuses(),
+		Cache:         cacheStats,
+		Notifications: notifications,
+		Message:       message,
+	}
+	component := layout(adminConsole(console), true)
+	return component.Render(c.Request().Context(), c.Response())
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openAPISpec describes the API. The Go client in client/ is generated from
+// it, so it has to be kept in sync with the handlers below.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiErrorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type formerNameRequest struct {
+	Name              string     `json:"name"`
+	NotificationEmail *string    `json:"notification_email"`
+	NotifyWhen        NotifyWhen `json:"notify_when"`
+}
+
+// updateFormerNameRequest changes only the fields it has.
+type updateFormerNameRequest struct {
+	NotificationEmail *string     `json:"notification_email"`
+	NotifyWhen        *NotifyWhen `json:"notify_when"`
+}
+
+// API serves the /api/v1 JSON endpoints on top of the same services as the
+// HTML pages.
+type API struct {
+	FormerNames *FormerNameService
+	Characters  *WatchedCharacterService
+}
+
+func (a *API) Register(g *echo.Group) {
+	g.GET("/former-names", a.ListFormerNames)
+	g.POST("/former-names", a.CreateFormerName)
+	g.GET("/former-names/:name", a.GetFormerName)
+	g.PATCH("/former-names/:name", a.UpdateFormerName)
+	g.DELETE("/former-names/:name", a.DeleteFormerName)
+	g.GET("/former-names/:name/history", a.FormerNameHistory)
+	g.POST("/former-names/:name/recheck", a.RecheckFormerName)
+	g.GET("/characters/:name", a.SearchCharacter)
+	g.GET("/watched-characters", a.ListWatchedCharacters)
+	g.POST("/watched-characters", a.WatchCharacter)
+	g.DELETE("/watched-characters/:name", a.UnwatchCharacter)
+	// Keeps unknown API paths from falling through to the static files.
+	g.Any("/*", func(c echo.Context) error {
+		return echo.ErrNotFound
+	})
+}
+
+func OpenAPISpec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openAPISpec)
+}
+
+func isAPIRequest(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().URL.Path, "/api/")
+}
+
+// apiHTTPError gives service errors the status code the API reports them
+// with. Anything it does not know about becomes a 500.
+func apiHTTPError(err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errFormerNameNotFound), errors.Is(err, errWatchedCharacterNotFound), errors.Is(err, errCharacterNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errFormerNameExists), errors.Is(err, errWatchedCharacterExists):
+		status = http.StatusConflict
+	case errors.Is(err, errFormerNameRequired), errors.Is(err, errInvalidName):
+		status = http.StatusBadRequest
+	case errors.Is(err, errSearchUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, errSearchFailed):
+		status = http.StatusBadGateway
+	default:
+		return err
+	}
+
+	// Upstream details stay in the logs.
+	message := err.Error()
+	switch status {
+	case http.StatusBadGateway:
+		message = errSearchFailed.Error()
+	case http.StatusServiceUnavailable:
+		message = errSearchUnavailable.Error()
+	}
+	return echo.NewHTTPError(status, message).SetInternal(err)
+}
+
+func apiErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusBadGateway:
+		return "upstream_error"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "internal_error"
+		}
+		return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+}
+
+func apiErrorJSON(c echo.Context, code int, message string) error {
+	return c.JSON(code, apiErrorResponse{Error: apiError{Code: apiErrorCode(code), Message: message}})
+}
+
+func (a *API) ListFormerNames(c echo.Context) error {
+	formerNames, err := a.FormerNames.List(c.Request().Context(), contextUser(c.Request().Context()).ID)
+	if err != nil {
+		return apiHTTPError(err)
+	}
+	if formerNames == nil {
+		formerNames = []FormerName{}
+	}
+
+	return c.JSON(http.StatusOK, formerNames)
+}
+
+func (a *API) CreateFormerName(c echo.Context) error {
+	var req formerNameRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	var notificationEmail string
+	if req.NotificationEmail != nil {
+		notificationEmail = *req.NotificationEmail
+	}
+
+	formerName, err := a.FormerNames.Track(c.Request().Context(), contextUser(c.Request().Context()).ID, req.Name, notificationEmail, req.NotifyWhen)
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, formerName)
+}
+
+func (a *API) GetFormerName(c echo.Context) error {
+	formerName, err := a.FormerNames.Get(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name"))
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, formerName)
+}
+
+func (a *API) UpdateFormerName(c echo.Context) error {
+	var req updateFormerNameRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if req.NotificationEmail == nil && req.NotifyWhen == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "notification_email or notify_when is required")
+	}
+
+	formerName, err := a.FormerNames.Update(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name"), req.NotificationEmail, req.NotifyWhen)
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, formerName)
+}
+
+func (a *API) DeleteFormerName(c echo.Context) error {
+	if err := a.FormerNames.Delete(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name")); err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type statusChangeResponse struct {
+	OldStatus FormerNameStatus `json:"old_status"`
+	NewStatus FormerNameStatus `json:"new_status"`
+	Changed   time.Time        `json:"changed"`
+}
+
+func (a *API) FormerNameHistory(c echo.Context) error {
+	changes, err := a.FormerNames.History(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name"))
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	history := make([]statusChangeResponse, len(changes))
+	for i, change := range changes {
+		history[i] = statusChangeResponse{
+			OldStatus: unknown.FromString(change.OldStatus),
+			NewStatus: unknown.FromString(change.NewStatus),
+			Changed:   change.Created,
+		}
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+func (a *API) RecheckFormerName(c echo.Context) error {
+	formerName, err := a.FormerNames.Recheck(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name"))
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, formerName)
+}
+
+func (a *API) SearchCharacter(c echo.Context) error {
+	searchCharacter, err := a.FormerNames.Search(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return apiHTTPError(err)
+	}
+	if !searchCharacter.Found {
+		return echo.NewHTTPError(http.StatusNotFound, "character not found: "+c.Param("name"))
+	}
+
+	return c.JSON(http.StatusOK, searchCharacter)
+}
+
+type watchedCharacterRequest struct {
+	Name              string `json:"name"`
+	NotificationEmail string `json:"notification_email"`
+}
+
+func (a *API) ListWatchedCharacters(c echo.Context) error {
+	watched, err := a.Characters.List(c.Request().Context(), contextUser(c.Request().Context()).ID)
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, watched)
+}
+
+func (a *API) WatchCharacter(c echo.Context) error {
+	var req watchedCharacterRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	watched, err := a.Characters.Watch(c.Request().Context(), contextUser(c.Request().Context()).ID, req.Name, req.NotificationEmail)
+	if err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, watched)
+}
+
+func (a *API) UnwatchCharacter(c echo.Context) error {
+	if err := a.Characters.Unwatch(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name")); err != nil {
+		return apiHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
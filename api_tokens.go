@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	apiTokenPrefix        = "tb_"
+	scopeReadOnly         = "read"
+	scopeReadWrite        = "write"
+	maxAPITokenNameLength = 100
+)
+
+var (
+	errInvalidAPIToken   = errors.New("invalid or revoked API token")
+	errAPITokenName      = errors.New("token name is required")
+	errAPITokenScope     = errors.New("scope must be read or write")
+	errAPITokenNotFound  = errors.New("token not found")
+	errAPITokenReadOnly  = errors.New("this token is read-only")
+	errAPITokenNameLimit = errors.New("token name is too long")
+)
+
+// createAPIToken returns the new token in plain text. Only its hash is
+// stored, so it can not be shown again.
+func (a AuthService) createAPIToken(userID int64, name, scope string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errAPITokenName
+	}
+	if len(name) > maxAPITokenNameLength {
+		return "", errAPITokenNameLimit
+	}
+	if scope != scopeReadOnly && scope != scopeReadWrite {
+		return "", errAPITokenScope
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token := apiTokenPrefix + secret
+	hashedToken := sha256.Sum256([]byte(token))
+
+	_, err = a.Db.CreateAPIToken(a.Ctx, sqlc.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        name,
+		HashedToken: hashedToken[:],
+		Scope:       scope,
+		Created:     time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (a AuthService) revokeAPIToken(userID, tokenID int64) error {
+	rows, err := a.Db.RevokeAPIToken(a.Ctx, sqlc.RevokeAPITokenParams{
+		Revoked: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:      tokenID,
+		UserID:  userID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errAPITokenNotFound
+	}
+
+	return nil
+}
+
+// authenticateAPIToken returns the token and its owner, and records that the
+// token was used.
+func (a AuthService) authenticateAPIToken(token string) (*sqlc.ApiToken, *sqlc.User, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return nil, nil, errInvalidAPIToken
+	}
+
+	hashedToken := sha256.Sum256([]byte(token))
+	apiToken, err := a.Db.GetAPIToken(a.Ctx, hashedToken[:])
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, errInvalidAPIToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := a.Db.GetUserByID(a.Ctx, apiToken.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errInvalidAPIToken
+	}
+
+	err = a.Db.TouchAPIToken(a.Ctx, sqlc.TouchAPITokenParams{
+		LastUsed: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:       apiToken.ID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &apiToken, &user, nil
+}
+
+func bearerToken(c echo.Context) string {
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// hasBearerToken tells whether an API request authenticates with a token
+// instead of the session cookie. Browsers never add the header on their own,
+// so these requests do not need a CSRF token either.
+func hasBearerToken(c echo.Context) bool {
+	return isAPIRequest(c) && bearerToken(c) != ""
+}
+
+// apiTokenAuth signs an API request in with its bearer token, and keeps
+// read-only tokens to safe methods.
+func (a *AuthService) apiTokenAuth(c echo.Context, next echo.HandlerFunc) error {
+	apiToken, user, err := a.authenticateAPIToken(bearerToken(c))
+	if errors.Is(err, errInvalidAPIToken) {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if apiToken.Scope != scopeReadWrite {
+			return echo.NewHTTPError(http.StatusForbidden, errAPITokenReadOnly.Error())
+		}
+	}
+
+	ctx := context.WithValue(c.Request().Context(), userContextKey{}, user)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	return next(c)
+}
+
+func (a *AuthService) APITokensPage(c echo.Context) error {
+	return a.renderAPITokensPage(c, "", nil)
+}
+
+func (a *AuthService) CreateAPIToken(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.createAPIToken(user.ID, c.FormValue("name"), c.FormValue("scope"))
+	if err != nil {
+		return a.renderAPITokensPage(c, "", err)
+	}
+
+	return a.renderAPITokensPage(c, token, nil)
+}
+
+func (a *AuthService) RevokeAPIToken(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid token id.")
+	}
+	if err := a.revokeAPIToken(user.ID, tokenID); err != nil {
+		return a.renderAPITokensPage(c, "", err)
+	}
+
+	return a.renderAPITokensPage(c, "", nil)
+}
+
+func (a *AuthService) renderAPITokensPage(c echo.Context, newToken string, err error) error {
+	user, userErr := a.currentUser(c)
+	if userErr != nil {
+		return userErr
+	}
+
+	var errorMsg *string
+	if err != nil {
+		if !errors.Is(err, errAPITokenName) && !errors.Is(err, errAPITokenScope) &&
+			!errors.Is(err, errAPITokenNotFound) && !errors.Is(err, errAPITokenNameLimit) {
+			return err
+		}
+		msg := err.Error()
+		errorMsg = &msg
+	}
+
+	tokens, listErr := a.Db.ListUserAPITokens(a.Ctx, user.ID)
+	if listErr != nil {
+		return listErr
+	}
+
+	component := layout(apiTokens(tokens, newToken, errorMsg), true)
+	return component.Render(c.Request().Context(), c.Response())
+}
@@ -2,33 +2,60 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log/slog"
 	"rustydoggobytes/tibiabuddy/sqlc"
-
-	_ "embed"
+	"slices"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-//go:embed schema.sql
-var ddl string
+const (
+	// Failed sign ins are counted over this window. An account is locked once
+	// it reaches maxFailedSignInsPerAccount failures since its last successful
+	// sign in, and an IP once it reaches maxFailedSignInsPerIP.
+	signInAttemptWindow        = 15 * time.Minute
+	maxFailedSignInsPerAccount = 5
+	maxFailedSignInsPerIP      = 20
+)
+
+var (
+	errInvalidCredentials = errors.New("invalid email or password")
+	errTooManyAttempts    = errors.New("too many failed sign in attempts, try again later")
+	errSignUpFailed       = errors.New("could not create an account with this email and password")
+	errAccountDisabled    = errors.New("this account has been disabled")
+)
 
-type UserSession = string
+// dummyPassword is compared against when the email is unknown so that a
+// failed lookup takes as long as a wrong password.
+var dummyPassword, _ = bcrypt.GenerateFromPassword([]byte("tibiabuddy"), bcrypt.DefaultCost)
 
 type AuthService struct {
-	Ctx context.Context
-	Db  *sqlc.Queries
+	Ctx   context.Context
+	Db    *sqlc.Queries
+	Email *emailClient
+	OIDC  *OIDCProvider
+	// BaseURL is the address the app is reachable at, used to build links
+	// in emails.
+	BaseURL string
+	// AdminEmails are made admins when they sign up, or at startup if they
+	// already have an account.
+	AdminEmails []string
+
+	sqlDB *sql.DB
 }
 
-func NewAuthService(db *sql.DB) *AuthService {
-	queries := sqlc.New(db)
-	ctx := context.Background()
-	if _, err := db.ExecContext(ctx, ddl); err != nil {
-		panic(err)
-	}
+func NewAuthService(db *sql.DB, email *emailClient, baseURL string) *AuthService {
 	return &AuthService{
-		Ctx: ctx,
-		Db:  queries,
+		Ctx:     context.Background(),
+		Db:      sqlc.New(db),
+		Email:   email,
+		BaseURL: baseURL,
+		sqlDB:   db,
 	}
 }
 
@@ -41,20 +68,121 @@ func (a AuthService) signUp(email, password string) (*sqlc.User, error) {
 		Email:          email,
 		HashedPassword: hashedPassword,
 	})
+	if err != nil {
+		slog.Error("sign up failed", "email", email, "err", err)
+		return nil, errSignUpFailed
+	}
+	if slices.Contains(a.AdminEmails, email) {
+		if err := a.Db.PromoteUser(a.Ctx, email); err != nil {
+			return nil, err
+		}
+		user.IsAdmin = true
+	}
 
-	return &user, err
+	return &user, nil
 }
 
-func (a AuthService) signIn(email, password string) (*sqlc.User, error) {
+func (a AuthService) promoteAdmins() error {
+	for _, email := range a.AdminEmails {
+		if err := a.Db.PromoteUser(a.Ctx, email); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a AuthService) signIn(email, password, ip string) (*sqlc.User, error) {
+	if err := a.checkSignInAttempts(email, ip); err != nil {
+		return nil, err
+	}
+
 	user, err := a.Db.GetUser(a.Ctx, email)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		bcrypt.CompareHashAndPassword(dummyPassword, []byte(password))
+		a.recordSignInAttempt(email, ip, false)
+		return nil, errInvalidCredentials
 	}
 
 	err = bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(password))
 	if err != nil {
-		return nil, err
+		a.recordSignInAttempt(email, ip, false)
+		return nil, errInvalidCredentials
 	}
 
+	if err := a.recordFirstFactor(&user, ip); err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errAccountDisabled
+	}
 	return &user, nil
 }
+
+// recordFirstFactor records the sign in of user as successful after the
+// password or the identity provider, unless it has two-factor
+// authentication. Then only the second step succeeds, as the first alone
+// would reset the lockout and let codes be guessed without end.
+func (a AuthService) recordFirstFactor(user *sqlc.User, ip string) error {
+	enabled, err := a.twoFactorEnabled(user.ID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		a.recordSignInAttempt(user.Email, ip, true)
+	}
+
+	return nil
+}
+
+func (a AuthService) checkSignInAttempts(email, ip string) error {
+	since := time.Now().UTC().Add(-signInAttemptWindow)
+
+	failures, err := a.Db.CountFailedLoginAttemptsByEmail(a.Ctx, sqlc.CountFailedLoginAttemptsByEmailParams{Email: email, Since: since})
+	if err != nil {
+		return err
+	}
+	if failures >= maxFailedSignInsPerAccount {
+		slog.Warn("sign in blocked, account is locked", "email", email)
+		return errTooManyAttempts
+	}
+
+	failures, err = a.Db.CountFailedLoginAttemptsByIP(a.Ctx, sqlc.CountFailedLoginAttemptsByIPParams{Ip: ip, Since: since})
+	if err != nil {
+		return err
+	}
+	if failures >= maxFailedSignInsPerIP {
+		slog.Warn("sign in blocked, ip is locked", "ip", ip)
+		return errTooManyAttempts
+	}
+
+	return nil
+}
+
+func (a AuthService) recordSignInAttempt(email, ip string, success bool) {
+	if !success {
+		slog.Warn("failed sign in", "email", email, "ip", ip)
+	}
+
+	err := a.Db.CreateLoginAttempt(a.Ctx, sqlc.CreateLoginAttemptParams{
+		Email:   email,
+		Ip:      ip,
+		Success: success,
+		Created: time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("failed to record sign in attempt", "err", err)
+	}
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"testing"
+	"time"
+)
+
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+	db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	return NewAuthService(db.Db, nil, "")
+}
+
+func TestSignInLockout(t *testing.T) {
+	type attempt struct {
+		email, ip string
+		success   bool
+		age       time.Duration
+	}
+	// failures returns n failed attempts for email from ip, the newest age
+	// ago.
+	failures := func(n int, email, ip string, age time.Duration) []attempt {
+		attempts := make([]attempt, n)
+		for i := range attempts {
+			attempts[i] = attempt{email, ip, false, age + time.Duration(n-i)*time.Second}
+		}
+		return attempts
+	}
+	// otherEmails returns n failed attempts from ip, each for another email.
+	otherEmails := func(n int, ip string) []attempt {
+		attempts := make([]attempt, n)
+		for i := range attempts {
+			attempts[i] = attempt{fmt.Sprintf("user%d@example.com", i), ip, false, time.Minute}
+		}
+		return attempts
+	}
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+		want     error
+	}{
+		{"no failures", nil, nil},
+		{"below the account limit", failures(maxFailedSignInsPerAccount-1, "alice@example.com", "10.0.0.2", time.Minute), nil},
+		{"account locked", failures(maxFailedSignInsPerAccount, "alice@example.com", "10.0.0.2", time.Minute), errTooManyAttempts},
+		{"account locked from another IP", failures(maxFailedSignInsPerAccount, "alice@example.com", "10.0.0.3", time.Minute), errTooManyAttempts},
+		{"failures before the window", failures(maxFailedSignInsPerAccount, "alice@example.com", "10.0.0.2", signInAttemptWindow), nil},
+		{"failures before a successful sign in", append(
+			failures(maxFailedSignInsPerAccount, "alice@example.com", "10.0.0.2", 2*time.Minute),
+			attempt{"alice@example.com", "10.0.0.2", true, time.Minute},
+		), nil},
+		{"failures after a successful sign in", append(
+			[]attempt{{"alice@example.com", "10.0.0.2", true, 2 * time.Minute}},
+			failures(maxFailedSignInsPerAccount, "alice@example.com", "10.0.0.2", time.Minute)...,
+		), errTooManyAttempts},
+		{"below the IP limit", otherEmails(maxFailedSignInsPerIP-1, "10.0.0.1"), nil},
+		{"IP locked", otherEmails(maxFailedSignInsPerIP, "10.0.0.1"), errTooManyAttempts},
+		{"other IP locked", otherEmails(maxFailedSignInsPerIP, "10.0.0.2"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthService(t)
+			if _, err := a.signUp("alice@example.com", "password"); err != nil {
+				t.Fatal(err)
+			}
+			for _, at := range tt.attempts {
+				err := a.Db.CreateLoginAttempt(a.Ctx, sqlc.CreateLoginAttemptParams{
+					Email:   at.email,
+					Ip:      at.ip,
+					Success: at.success,
+					Created: time.Now().UTC().Add(-at.age),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := a.signIn("alice@example.com", "password", "10.0.0.1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignInCountsFailures(t *testing.T) {
+	a := newTestAuthService(t)
+	if _, err := a.signUp("alice@example.com", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	for range maxFailedSignInsPerAccount {
+		if _, err := a.signIn("alice@example.com", "wrong", "10.0.0.1"); !errors.Is(err, errInvalidCredentials) {
+			t.Fatalf("err = %v, want %v", err, errInvalidCredentials)
+		}
+	}
+	if _, err := a.signIn("alice@example.com", "password", "10.0.0.2"); !errors.Is(err, errTooManyAttempts) {
+		t.Errorf("err = %v, want %v", err, errTooManyAttempts)
+	}
+
+	// Unknown emails count against the IP like wrong passwords.
+	for i := range maxFailedSignInsPerIP - maxFailedSignInsPerAccount {
+		if _, err := a.signIn(fmt.Sprintf("user%d@example.com", i), "password", "10.0.0.1"); !errors.Is(err, errInvalidCredentials) {
+			t.Fatalf("err = %v, want %v", err, errInvalidCredentials)
+		}
+	}
+	if _, err := a.signIn("bob@example.com", "password", "10.0.0.1"); !errors.Is(err, errTooManyAttempts) {
+		t.Errorf("err = %v, want %v", err, errTooManyAttempts)
+	}
+}
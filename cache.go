@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Stale responses are kept this long so they can still be revalidated
+	// with a conditional request instead of being fetched again.
+	cacheRetention = 24 * time.Hour
+	// Expired entries are pruned after every this many stored responses.
+	cachePruneInterval = 100
+	// Responses larger than this are passed through without being cached.
+	maxCachedResponseSize = 1 << 20
+)
+
+// cacheStore keeps responses by URL. Get returns nil for a URL it does not
+// have.
+type cacheStore interface {
+	Get(ctx context.Context, url string) (*sqlc.ResponseCache, error)
+	Put(ctx context.Context, response sqlc.ResponseCache) error
+	Delete(ctx context.Context, url string) error
+	Prune(ctx context.Context, before time.Time) error
+}
+
+type memoryCacheStore struct {
+	mu        sync.Mutex
+	responses map[string]sqlc.ResponseCache
+}
+
+func (s *memoryCacheStore) Get(ctx context.Context, url string) (*sqlc.ResponseCache, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response, ok := s.responses[url]
+	if !ok {
+		return nil, nil
+	}
+
+	return &response, nil
+}
+
+func (s *memoryCacheStore) Put(ctx context.Context, response sqlc.ResponseCache) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.responses == nil {
+		s.responses = map[string]sqlc.ResponseCache{}
+	}
+	s.responses[response.Url] = response
+
+	return nil
+}
+
+func (s *memoryCacheStore) Delete(ctx context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, url)
+
+	return nil
+}
+
+func (s *memoryCacheStore) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for url, response := range s.responses {
+		if response.Fetched.Before(before) {
+			delete(s.responses, url)
+		}
+	}
+
+	return nil
+}
+
+// sqliteCacheStore keeps responses in the database, so they survive
+// restarts and are shared by the server and poll-once.
+type sqliteCacheStore struct {
+	Queries *sqlc.Queries
+}
+
+func (s sqliteCacheStore) Get(ctx context.Context, url string) (*sqlc.ResponseCache, error) {
+	response, err := s.Queries.GetCachedResponse(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (s sqliteCacheStore) Put(ctx context.Context, response sqlc.ResponseCache) error {
+	return s.Queries.PutCachedResponse(ctx, sqlc.PutCachedResponseParams(response))
+}
+
+func (s sqliteCacheStore) Delete(ctx context.Context, url string) error {
+	return s.Queries.DeleteCachedResponse(ctx, url)
+}
+
+func (s sqliteCacheStore) Prune(ctx context.Context, before time.Time) error {
+	return s.Queries.DeleteCachedResponsesBefore(ctx, before.UTC())
+}
+
+// CacheStats counts how lookups were answered: from the cache, by a
+// conditional request that found the cached response unchanged, by a full
+// request because nothing usable was cached, or by a full request because
+// the caller asked to bypass the cache.
+type CacheStats struct {
+	Store       string
+	TTL         time.Duration
+	Hits        int64
+	Revalidated int64
+	Misses      int64
+	Bypassed    int64
+}
+
+// HitRatio is the share of lookups answered without a full request.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Revalidated + s.Misses + s.Bypassed
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Hits+s.Revalidated) / float64(total)
+}
+
+// responseCache answers GET requests from Store while the cached response is
+// younger than TTL. Older responses are revalidated with If-None-Match or
+// If-Modified-Since when the upstream sent an ETag or Last-Modified. Only
+// 200 responses are cached: errors are retried right away, and a name that
+// was not found is looked up again in case it has just been freed.
+type responseCache struct {
+	Store     cacheStore
+	StoreName string
+	TTL       time.Duration
+
+	hits        atomic.Int64
+	revalidated atomic.Int64
+	misses      atomic.Int64
+	bypassed    atomic.Int64
+	puts        atomic.Int64
+}
+
+// newResponseCache returns the configured cache, nil when the TTL is zero.
+func newResponseCache(config *Config, db *repositoryClient) *responseCache {
+	if config.CacheTTL == 0 {
+		return nil
+	}
+
+	cache := &responseCache{StoreName: config.CacheStore, TTL: time.Duration(config.CacheTTL)}
+	if config.CacheStore == "sqlite" {
+		cache.Store = sqliteCacheStore{Queries: sqlc.New(db.Db)}
+	} else {
+		cache.Store = &memoryCacheStore{}
+	}
+
+	return cache
+}
+
+func (c *responseCache) Stats() CacheStats {
+	return CacheStats{
+		Store:       c.StoreName,
+		TTL:         c.TTL,
+		Hits:        c.hits.Load(),
+		Revalidated: c.revalidated.Load(),
+		Misses:      c.misses.Load(),
+		Bypassed:    c.bypassed.Load(),
+	}
+}
+
+// Transport returns a transport that goes through the cache before sending
+// requests with next.
+func (c *responseCache) Transport(next http.RoundTripper) http.RoundTripper {
+	return cacheTransport{cache: c, next: next}
+}
+
+type bypassCacheKey struct{}
+
+// withoutCache makes lookups with ctx skip cached responses, for re-checks
+// that have to see the current state. The response still updates the cache.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func bypassesCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+type cacheHitKey struct{}
+
+// withCacheHit returns a context whose requests set the returned flag when
+// they are answered from the cache without asking the upstream, so that
+// the caller can leave such answers out of health and latency accounting.
+func withCacheHit(ctx context.Context) (context.Context, *atomic.Bool) {
+	hit := &atomic.Bool{}
+	return context.WithValue(ctx, cacheHitKey{}, hit), hit
+}
+
+type cacheTransport struct {
+	cache *responseCache
+	next  http.RoundTripper
+}
+
+func (t cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	logger := logFromContext(ctx)
+	url := req.URL.String()
+
+	cached, err := t.cache.Store.Get(ctx, url)
+	if err != nil {
+		logger.Error("failed to read cached response", "url", url, "err", err)
+		cached = nil
+	}
+	bypass := bypassesCache(ctx)
+	if cached != nil && !bypass && time.Since(cached.Fetched) < t.cache.TTL {
+		t.cache.count(&t.cache.hits, "hit")
+		if hit, ok := ctx.Value(cacheHitKey{}).(*atomic.Bool); ok {
+			hit.Store(true)
+		}
+		return cachedHTTPResponse(req, cached), nil
+	}
+
+	if cached != nil && (cached.Etag != "" || cached.LastModified != "") {
+		req = req.Clone(ctx)
+		if cached.Etag != "" {
+			req.Header.Set("If-None-Match", cached.Etag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		t.cache.count(&t.cache.revalidated, "revalidated")
+		cached.Fetched = time.Now()
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			cached.Etag = etag
+		}
+		t.cache.put(ctx, *cached)
+		return cachedHTTPResponse(req, cached), nil
+	}
+
+	if bypass {
+		t.cache.count(&t.cache.bypassed, "bypass")
+	} else {
+		t.cache.count(&t.cache.misses, "miss")
+	}
+	if resp.StatusCode != http.StatusOK {
+		// A bypassing lookup may find that the cached response no longer
+		// holds, which must not be answered from the cache afterwards.
+		if cached != nil {
+			if err := t.cache.Store.Delete(ctx, url); err != nil {
+				logger.Error("failed to delete cached response", "url", url, "err", err)
+			}
+		}
+		return resp, nil
+	}
+	if resp.ContentLength > maxCachedResponseSize {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedResponseSize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxCachedResponseSize {
+		return resp, nil
+	}
+
+	t.cache.put(ctx, sqlc.ResponseCache{
+		Url:          url,
+		StatusCode:   int64(resp.StatusCode),
+		Body:         body,
+		Etag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	})
+
+	return resp, nil
+}
+
+func (c *responseCache) count(counter *atomic.Int64, result string) {
+	counter.Add(1)
+	cacheLookupsTotal.WithLabelValues(result).Inc()
+}
+
+func (c *responseCache) put(ctx context.Context, response sqlc.ResponseCache) {
+	logger := logFromContext(ctx)
+	response.Fetched = response.Fetched.UTC()
+	if err := c.Store.Put(ctx, response); err != nil {
+		logger.Error("failed to cache response", "url", response.Url, "err", err)
+		return
+	}
+
+	if c.puts.Add(1)%cachePruneInterval == 0 {
+		if err := c.Store.Prune(ctx, time.Now().Add(-cacheRetention)); err != nil {
+			logger.Error("failed to prune cached responses", "err", err)
+		}
+	}
+}
+
+func cachedHTTPResponse(req *http.Request, cached *sqlc.ResponseCache) *http.Response {
+	header := http.Header{}
+	if cached.Etag != "" {
+		header.Set("ETag", cached.Etag)
+	}
+	if cached.LastModified != "" {
+		header.Set("Last-Modified", cached.LastModified)
+	}
+	header.Set("Age", strconv.Itoa(int(time.Since(cached.Fetched).Seconds())))
+
+	return &http.Response{
+		Status:        http.StatusText(int(cached.StatusCode)),
+		StatusCode:    int(cached.StatusCode),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
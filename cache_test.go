@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheTransport(t *testing.T) {
+	type upstream struct {
+		status             int
+		body               string
+		etag, lastModified string
+	}
+	// A step is one GET. upstream, when set, changes what the upstream
+	// answers from then on, and stale makes the cached response older than
+	// the TTL first.
+	type step struct {
+		upstream      *upstream
+		stale, bypass bool
+		status        int
+		body          string
+		// requests is how many requests reached the upstream so far.
+		requests int
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  CacheStats
+	}{
+		{"fresh response from the cache", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1"}, status: http.StatusOK, body: "v1", requests: 1},
+			{status: http.StatusOK, body: "v1", requests: 1},
+		}, CacheStats{Hits: 1, Misses: 1}},
+		{"stale response without validators fetched again", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1"}, status: http.StatusOK, body: "v1", requests: 1},
+			{upstream: &upstream{status: http.StatusOK, body: "v2"}, stale: true, status: http.StatusOK, body: "v2", requests: 2},
+			{status: http.StatusOK, body: "v2", requests: 2},
+		}, CacheStats{Hits: 1, Misses: 2}},
+		{"stale response revalidated by ETag", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1", etag: `"1"`}, status: http.StatusOK, body: "v1", requests: 1},
+			{stale: true, status: http.StatusOK, body: "v1", requests: 2},
+			{status: http.StatusOK, body: "v1", requests: 2},
+		}, CacheStats{Hits: 1, Revalidated: 1, Misses: 1}},
+		{"stale response revalidated by Last-Modified", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1", lastModified: "Mon, 02 Jun 2025 10:00:00 GMT"}, status: http.StatusOK, body: "v1", requests: 1},
+			{stale: true, status: http.StatusOK, body: "v1", requests: 2},
+		}, CacheStats{Revalidated: 1, Misses: 1}},
+		{"changed ETag replaces the cached response", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1", etag: `"1"`}, status: http.StatusOK, body: "v1", requests: 1},
+			{upstream: &upstream{status: http.StatusOK, body: "v2", etag: `"2"`}, stale: true, status: http.StatusOK, body: "v2", requests: 2},
+			{status: http.StatusOK, body: "v2", requests: 2},
+		}, CacheStats{Hits: 1, Misses: 2}},
+		{"bypass asks the upstream and updates the cache", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1"}, status: http.StatusOK, body: "v1", requests: 1},
+			{upstream: &upstream{status: http.StatusOK, body: "v2"}, bypass: true, status: http.StatusOK, body: "v2", requests: 2},
+			{status: http.StatusOK, body: "v2", requests: 2},
+		}, CacheStats{Hits: 1, Misses: 1, Bypassed: 1}},
+		{"bypass revalidates", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1", etag: `"1"`}, status: http.StatusOK, body: "v1", requests: 1},
+			{bypass: true, status: http.StatusOK, body: "v1", requests: 2},
+		}, CacheStats{Revalidated: 1, Misses: 1}},
+		{"not found is not cached", []step{
+			{upstream: &upstream{status: http.StatusNotFound, body: "none"}, status: http.StatusNotFound, body: "none", requests: 1},
+			{upstream: &upstream{status: http.StatusOK, body: "v1"}, status: http.StatusOK, body: "v1", requests: 2},
+		}, CacheStats{Misses: 2}},
+		{"errors are not cached", []step{
+			{upstream: &upstream{status: http.StatusBadGateway, body: "down"}, status: http.StatusBadGateway, body: "down", requests: 1},
+			{status: http.StatusBadGateway, body: "down", requests: 2},
+		}, CacheStats{Misses: 2}},
+		{"bypass finding the response gone drops it", []step{
+			{upstream: &upstream{status: http.StatusOK, body: "v1"}, status: http.StatusOK, body: "v1", requests: 1},
+			{upstream: &upstream{status: http.StatusNotFound, body: "none"}, bypass: true, status: http.StatusNotFound, body: "none", requests: 2},
+			{status: http.StatusNotFound, body: "none", requests: 3},
+		}, CacheStats{Misses: 2, Bypassed: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current upstream
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if current.etag != "" {
+					w.Header().Set("ETag", current.etag)
+					if r.Header.Get("If-None-Match") == current.etag {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				if current.lastModified != "" {
+					w.Header().Set("Last-Modified", current.lastModified)
+					if r.Header.Get("If-Modified-Since") == current.lastModified {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				w.WriteHeader(current.status)
+				io.WriteString(w, current.body)
+			}))
+			defer srv.Close()
+
+			store := &memoryCacheStore{}
+			cache := &responseCache{Store: store, TTL: time.Minute}
+			client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+			for i, s := range tt.steps {
+				if s.upstream != nil {
+					current = *s.upstream
+				}
+				if s.stale {
+					cached := store.responses[srv.URL]
+					cached.Fetched = cached.Fetched.Add(-cache.TTL)
+					store.responses[srv.URL] = cached
+				}
+				ctx := context.Background()
+				if s.bypass {
+					ctx = withoutCache(ctx)
+				}
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if resp.StatusCode != s.status || string(body) != s.body || requests != s.requests {
+					t.Errorf("step %d: status, body, requests = %d, %q, %d, want %d, %q, %d", i, resp.StatusCode, body, requests, s.status, s.body, s.requests)
+				}
+			}
+
+			tt.want.TTL = cache.TTL
+			if got := cache.Stats(); got != tt.want {
+				t.Errorf("stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheTransportPassesOtherMethods(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	cache := &responseCache{Store: &memoryCacheStore{}, TTL: time.Minute}
+	client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+	for range 2 {
+		resp, err := client.Post(srv.URL, "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+	if got := cache.Stats(); got != (CacheStats{TTL: cache.TTL}) {
+		t.Errorf("stats = %+v, want none", got)
+	}
+}
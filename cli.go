@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strings"
+	"time"
+)
+
+const usage = `usage: tibiabuddy [flags] [command] [arguments]
+
+commands:
+  serve                           run the web server and the poller (default)
+  poll-once                       check every tracked name once and exit
+  check <name>                    print the status a name would get right now
+  migrate                         apply pending database migrations
+  user create [-admin] <email>    create a user, the password is read from stdin
+  user disable <email>            stop a user from signing in
+  user enable <email>             let a disabled user sign in again
+  user promote <email>            make a user an admin
+  names export [-user <email>]    write tracked names as JSON to stdout
+  names import [-user <email>]    read tracked names as JSON from stdin
+  names adopt <email>             give the names tracked before there were
+                                  accounts to a user
+  notify test <email> [name]      send a test notification`
+
+var errUsage = errors.New(usage)
+
+// exportedName is a tracked name as written by names export and read by names
+// import. User is the owner's email, as ids differ between installs.
+type exportedName struct {
+	User string `json:"user"`
+	FormerName
+}
+
+func runCommand(config *Config, command string, args []string) error {
+	switch command {
+	case "serve":
+		return serve(config)
+	case "poll-once":
+		return pollOnceCommand(config)
+	case "check":
+		return checkCommand(config, args)
+	case "migrate":
+		return migrateCommand(config)
+	case "user":
+		return userCommand(config, args)
+	case "names":
+		return namesCommand(config, args)
+	case "notify":
+		return notifyCommand(config, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+		return nil
+	}
+
+	return errUsage
+}
+
+func newCommandPoller(config *Config) (*Poller, error) {
+	if err := config.ValidateEmail(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	db, err := RepositoryClient(config.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	emailClient := EmailClient(config.ResendAPIToken, config.EmailFrom)
+	t, err := NewTibiaDataApi(config, newResponseCache(config, db))
+	if err != nil {
+		return nil, err
+	}
+	poller := NewPoller(db, t, &emailClient)
+	poller.NameDelay = time.Duration(config.PollNameDelay)
+
+	return poller, nil
+}
+
+func pollOnceCommand(config *Config) error {
+	poller, err := newCommandPoller(config)
+	if err != nil {
+		return err
+	}
+	defer poller.Db.Close()
+
+	if err := poller.pass(); err != nil {
+		return err
+	}
+	if lastError := poller.State().LastError; lastError != "" {
+		return fmt.Errorf("pass finished with errors, last one: %s", lastError)
+	}
+
+	return nil
+}
+
+func checkCommand(config *Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	name := args[0]
+
+	// Shows what the mirrors answer right now, so there is no cache.
+	t, err := NewTibiaDataApi(config, nil)
+	if err != nil {
+		return err
+	}
+	char, err := t.SearchCharacter(context.Background(), name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(getNewStatus(char))
+	if char.Found {
+		fmt.Printf("held by %s on %s, former names: %s\n", char.Name, char.World, strings.Join(char.FormerNames, ", "))
+	}
+
+	return nil
+}
+
+func migrateCommand(config *Config) error {
+	db, err := sql.Open("sqlite", config.DatabasePath+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var from, to int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&from); err != nil {
+		return err
+	}
+	if err := migrate(db); err != nil {
+		return err
+	}
+	if err := db.QueryRow("PRAGMA user_version").Scan(&to); err != nil {
+		return err
+	}
+
+	if from == to {
+		fmt.Printf("database is up to date at version %d\n", to)
+	} else {
+		fmt.Printf("migrated database from version %d to %d\n", from, to)
+	}
+
+	return nil
+}
+
+func userCommand(config *Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	admin := flags.Bool("admin", false, "make the new user an admin")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+	email := flags.Arg(0)
+
+	db, err := RepositoryClient(config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	authService := NewAuthService(db.Db, nil, "")
+
+	if args[0] == "create" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && password == "" {
+			return err
+		}
+		password = strings.TrimRight(password, "\r\n")
+		if password == "" {
+			return errors.New("password is required")
+		}
+
+		if *admin {
+			authService.AdminEmails = []string{email}
+		}
+		user, err := authService.signUp(email, password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %d %s\n", user.ID, user.Email)
+		return nil
+	}
+
+	user, err := authService.Db.GetUser(authService.Ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no user with email %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	var done string
+	switch args[0] {
+	case "disable", "enable":
+		err = authService.Db.SetUserDisabled(authService.Ctx, sqlc.SetUserDisabledParams{
+			Disabled: args[0] == "disable",
+			ID:       user.ID,
+		})
+		done = args[0] + "d"
+	case "promote":
+		err = authService.Db.PromoteUser(authService.Ctx, user.Email)
+		done = "promoted"
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s user %d %s\n", done, user.ID, user.Email)
+	return nil
+}
+
+func namesCommand(config *Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if args[0] == "adopt" {
+		if len(args) != 2 {
+			return errUsage
+		}
+		return adoptNames(config, args[1])
+	}
+	flags := flag.NewFlagSet("names "+args[0], flag.ContinueOnError)
+	userEmail := flags.String("user", "", "only export names of this user, or import all names for this user")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	db, err := RepositoryClient(config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	authService := NewAuthService(db.Db, nil, "")
+
+	switch args[0] {
+	case "export":
+		return exportNames(db, authService, *userEmail)
+	case "import":
+		return importNames(db, authService, *userEmail)
+	}
+
+	return errUsage
+}
+
+// adoptNames gives the names without an owner, left over from before there
+// were accounts, to the user with email. Names the user already tracks stay
+// without an owner.
+func adoptNames(config *Config, email string) error {
+	db, err := RepositoryClient(config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	authService := NewAuthService(db.Db, nil, "")
+
+	user, err := authService.Db.GetUser(authService.Ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no user with email %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	adopted, err := authService.Db.AdoptUnownedFormerNames(authService.Ctx, sql.NullInt64{Int64: user.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	left, err := authService.Db.CountUnownedFormerNames(authService.Ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("gave %d names to user %d %s\n", adopted, user.ID, user.Email)
+	if left > 0 {
+		fmt.Printf("%d names the user already tracks are left without an owner\n", left)
+	}
+	return nil
+}
+
+func exportNames(db *repositoryClient, a *AuthService, userEmail string) error {
+	users, err := a.Db.ListUsers(a.Ctx)
+	if err != nil {
+		return err
+	}
+	emails := make(map[int64]string, len(users))
+	for _, user := range users {
+		emails[user.ID] = user.Email
+	}
+
+	formerNames, err := db.GetFormerNames(context.Background())
+	if err != nil {
+		return err
+	}
+	exported := []exportedName{}
+	for _, formerName := range formerNames {
+		if userEmail != "" && emails[formerName.UserID] != userEmail {
+			continue
+		}
+		exported = append(exported, exportedName{User: emails[formerName.UserID], FormerName: formerName})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exported)
+}
+
+// importNames adds the names read from stdin, replacing names the owner
+// already tracks, so an export can be imported again after changes.
+func importNames(db *repositoryClient, a *AuthService, userEmail string) error {
+	var imported []exportedName
+	if err := json.NewDecoder(os.Stdin).Decode(&imported); err != nil {
+		return err
+	}
+
+	userIDs := map[string]int64{}
+	for _, formerName := range imported {
+		email := formerName.User
+		if userEmail != "" {
+			email = userEmail
+		}
+		formerName.Name = normalizeName(formerName.Name)
+		if formerName.Name == "" {
+			return errFormerNameRequired
+		}
+
+		userID, ok := userIDs[email]
+		if !ok {
+			user, err := a.Db.GetUser(a.Ctx, email)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no user with email %q for %s", email, formerName.Name)
+			}
+			if err != nil {
+				return err
+			}
+			userID = user.ID
+			userIDs[email] = userID
+		}
+
+		formerName.UserID = userID
+		if err := db.SaveFormerName(context.Background(), formerName.FormerName); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("imported %d names\n", len(imported))
+	return nil
+}
+
+func notifyCommand(config *Config, args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[0] != "test" {
+		return errUsage
+	}
+	name := "Test Name"
+	if len(args) == 3 {
+		name = args[2]
+	}
+
+	poller, err := newCommandPoller(config)
+	if err != nil {
+		return err
+	}
+	defer poller.Db.Close()
+
+	return poller.Email.NotifyUserFormerNameIsAvailable(context.Background(), strings.Split(args[1], ","), name)
+}
@@ -0,0 +1,5 @@
+package client
+
+// client.gen.go is generated from the OpenAPI document served at
+// /api/openapi.json. Run go generate after changing it.
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -config oapi-codegen.yaml ../openapi.json
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds everything that can differ between installs. Values are taken
+// from the defaults, then the JSON file given with -config or CONFIG_FILE,
+// then the environment (including .env), then the command line flags.
+type Config struct {
+	DatabasePath  string `json:"database_path"`
+	ListenAddress string `json:"listen_address"`
+	BaseURL       string `json:"base_url"`
+	// MetricsListenAddress serves /metrics on its own for Prometheus, meant
+	// to be reachable only from the internal network. On ListenAddress only
+	// admins can read it.
+	MetricsListenAddress string `json:"metrics_listen_address"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For header gives the client IP. Without any, the
+	// address of the connection is used and the header ignored.
+	TrustedProxies []string `json:"trusted_proxies"`
+	// TibiaDataURLs are the TibiaData mirrors, tried in order. A mirror is
+	// skipped for TibiaDataBreakerCooldown after failing repeatedly.
+	TibiaDataURLs            []string `json:"tibiadata_urls"`
+	TibiaDataBreakerCooldown Duration `json:"tibiadata_breaker_cooldown"`
+	// TibiaComMode is "fallback" to read character pages of TibiaComURL when
+	// no mirror answers, "primary" to try them before the mirrors or "off".
+	TibiaComMode string `json:"tibiacom_mode"`
+	TibiaComURL  string `json:"tibiacom_url"`
+	// CacheTTL is how long lookups are answered from the cache, 0 turns it
+	// off. CacheStore is "memory" or "sqlite".
+	CacheTTL   Duration `json:"cache_ttl"`
+	CacheStore string   `json:"cache_store"`
+	// PollInterval is the time between two passes over all tracked names and
+	// PollNameDelay the time between two names within a pass.
+	PollInterval  Duration `json:"poll_interval"`
+	PollNameDelay Duration `json:"poll_name_delay"`
+	// PollStallTimeout is how long the poller may go without finishing a
+	// pass before /healthz fails, TibiaDataReadyTimeout how long TibiaData
+	// may keep failing before /readyz fails.
+	PollStallTimeout      Duration `json:"poll_stall_timeout"`
+	TibiaDataReadyTimeout Duration `json:"tibiadata_ready_timeout"`
+	// LogFormat is "text" or "json", LogLevel one of debug, info, warn and
+	// error.
+	LogFormat string `json:"log_format"`
+	LogLevel  string `json:"log_level"`
+	// TracingExporter is "none", "otlp" or "stdout". TracingEndpoint is the
+	// OTLP/HTTP URL, the OTEL_EXPORTER_OTLP_* variables apply when it is empty.
+	TracingExporter string `json:"tracing_exporter"`
+	TracingEndpoint string `json:"tracing_endpoint"`
+
+	SessionStoreSecret string   `json:"session_store_secret"`
+	ResendAPIToken     string   `json:"resend_api_token"`
+	EmailFrom          string   `json:"email_from"`
+	AdminEmails        []string `json:"admin_emails"`
+
+	OIDC OIDCConfig `json:"oidc"`
+}
+
+type OIDCConfig struct {
+	IssuerURL    string `json:"issuer_url"`
+	ProviderName string `json:"provider_name"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RedirectURL  string `json:"redirect_url"`
+}
+
+// Duration reads and writes durations as strings like "5m" so they can be set
+// the same way in the config file, the environment and flags.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		DatabasePath:             "data/tibiabuddy.db",
+		ListenAddress:            "0.0.0.0:8080",
+		TibiaDataURLs:            []string{"https://tibiadata.rustydoggobytes.com"},
+		TibiaDataBreakerCooldown: Duration(1 * time.Minute),
+		TibiaComMode:             "fallback",
+		TibiaComURL:              "https://www.tibia.com",
+		CacheTTL:                 Duration(1 * time.Minute),
+		CacheStore:               "memory",
+		PollInterval:             Duration(5 * time.Minute),
+		PollNameDelay:            Duration(1 * time.Second),
+		PollStallTimeout:         Duration(30 * time.Minute),
+		TibiaDataReadyTimeout:    Duration(30 * time.Minute),
+		LogFormat:                "text",
+		LogLevel:                 "info",
+		TracingExporter:          "none",
+		OIDC: OIDCConfig{
+			ProviderName: "OIDC",
+		},
+	}
+}
+
+// LoadConfig builds the config and returns the arguments left after the
+// flags, which are the command and its arguments.
+func LoadConfig(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf(".env: %w", err)
+	}
+
+	config := defaultConfig()
+	flags := flag.NewFlagSet("tibiabuddy", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		fmt.Fprintln(flags.Output(), "\nflags:")
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "JSON config `file`")
+	flags.StringVar(&config.DatabasePath, "db", config.DatabasePath, "SQLite database `path`")
+	flags.StringVar(&config.ListenAddress, "listen", config.ListenAddress, "`address` the web server listens on")
+	flags.StringVar(&config.MetricsListenAddress, "metrics-listen", config.MetricsListenAddress, "`address` /metrics is served on for scraping without signing in")
+	flags.StringVar(&config.BaseURL, "base-url", config.BaseURL, "public `URL` of the site, used in links in emails")
+	flags.Func("trusted-proxies", "comma separated `addresses` or CIDR ranges of reverse proxies trusted for X-Forwarded-For", func(s string) error {
+		config.TrustedProxies = splitList(s)
+		return nil
+	})
+	flags.Func("tibiadata-url", "comma separated TibiaData API `URLs`, or file:// URLs of recorded responses (default "+strings.Join(config.TibiaDataURLs, ",")+")", func(s string) error {
+		config.TibiaDataURLs = splitList(s)
+		return nil
+	})
+	flags.StringVar(&config.TibiaComMode, "tibiacom-mode", config.TibiaComMode, "when to read character pages of tibia.com, `mode` fallback, primary or off")
+	flags.StringVar(&config.TibiaComURL, "tibiacom-url", config.TibiaComURL, "tibia.com `URL`, or a file:// URL of saved pages")
+	flags.TextVar(&config.CacheTTL, "cache-ttl", config.CacheTTL, "`duration` lookups are answered from the cache, 0 to turn it off")
+	flags.StringVar(&config.CacheStore, "cache-store", config.CacheStore, "where to cache lookups, `store` memory or sqlite")
+	flags.TextVar(&config.TibiaDataBreakerCooldown, "tibiadata-breaker-cooldown", config.TibiaDataBreakerCooldown, "`duration` a failing TibiaData mirror is skipped")
+	flags.TextVar(&config.PollInterval, "poll-interval", config.PollInterval, "`duration` between two passes over all names")
+	flags.TextVar(&config.PollNameDelay, "poll-name-delay", config.PollNameDelay, "`duration` between two names within a pass")
+	flags.TextVar(&config.PollStallTimeout, "poll-stall-timeout", config.PollStallTimeout, "`duration` without a finished pass after which /healthz fails")
+	flags.TextVar(&config.TibiaDataReadyTimeout, "tibiadata-ready-timeout", config.TibiaDataReadyTimeout, "`duration` of failing TibiaData requests after which /readyz fails")
+	flags.StringVar(&config.LogFormat, "log-format", config.LogFormat, "log `format`, text or json")
+	flags.StringVar(&config.LogLevel, "log-level", config.LogLevel, "minimum log `level`, debug, info, warn or error")
+	flags.StringVar(&config.TracingExporter, "tracing-exporter", config.TracingExporter, "where to send traces, `none`, otlp or stdout")
+	flags.StringVar(&config.TracingEndpoint, "tracing-endpoint", config.TracingEndpoint, "OTLP/HTTP `URL` for traces")
+
+	// The flags are parsed once to find the config file and again after the
+	// file and the environment are applied, so that they take precedence.
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := config.loadEnv(); err != nil {
+		return nil, nil, err
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.OIDC.RedirectURL == "" && config.BaseURL != "" {
+		config.OIDC.RedirectURL = config.BaseURL + "/signin/oidc/callback"
+	}
+
+	return &config, flags.Args(), config.Validate()
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	values := map[string]*string{
+		"DATABASE_PATH":          &c.DatabasePath,
+		"LISTEN_ADDRESS":         &c.ListenAddress,
+		"BASE_URL":               &c.BaseURL,
+		"METRICS_LISTEN_ADDRESS": &c.MetricsListenAddress,
+		"TIBIACOM_MODE":          &c.TibiaComMode,
+		"TIBIACOM_URL":           &c.TibiaComURL,
+		"CACHE_STORE":            &c.CacheStore,
+		"LOG_FORMAT":             &c.LogFormat,
+		"LOG_LEVEL":              &c.LogLevel,
+		"TRACING_EXPORTER":       &c.TracingExporter,
+		"TRACING_ENDPOINT":       &c.TracingEndpoint,
+		"SESSION_STORE_SECRET":   &c.SessionStoreSecret,
+		"RESEND_API_TOKEN":       &c.ResendAPIToken,
+		"EMAIL":                  &c.EmailFrom,
+		"OIDC_ISSUER_URL":        &c.OIDC.IssuerURL,
+		"OIDC_PROVIDER_NAME":     &c.OIDC.ProviderName,
+		"OIDC_CLIENT_ID":         &c.OIDC.ClientID,
+		"OIDC_CLIENT_SECRET":     &c.OIDC.ClientSecret,
+		"OIDC_REDIRECT_URL":      &c.OIDC.RedirectURL,
+	}
+	for name, value := range values {
+		if env, ok := os.LookupEnv(name); ok && env != "" {
+			*value = env
+		}
+	}
+
+	durations := map[string]*Duration{
+		"POLL_INTERVAL":              &c.PollInterval,
+		"POLL_NAME_DELAY":            &c.PollNameDelay,
+		"POLL_STALL_TIMEOUT":         &c.PollStallTimeout,
+		"TIBIADATA_READY_TIMEOUT":    &c.TibiaDataReadyTimeout,
+		"TIBIADATA_BREAKER_COOLDOWN": &c.TibiaDataBreakerCooldown,
+		"CACHE_TTL":                  &c.CacheTTL,
+	}
+	for name, value := range durations {
+		if env, ok := os.LookupEnv(name); ok && env != "" {
+			if err := value.UnmarshalText([]byte(env)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	// TIBIADATA_URL is the name from when there was only one mirror.
+	for _, name := range []string{"TIBIADATA_URL", "TIBIADATA_URLS"} {
+		if env := os.Getenv(name); env != "" {
+			c.TibiaDataURLs = splitList(env)
+		}
+	}
+	if env := os.Getenv("ADMIN_EMAILS"); env != "" {
+		c.AdminEmails = splitList(env)
+	}
+	if env := os.Getenv("TRUSTED_PROXIES"); env != "" {
+		c.TrustedProxies = splitList(env)
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// Validate checks the settings every command needs.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.DatabasePath == "" {
+		errs = append(errs, errors.New("database path is required"))
+	}
+	if len(c.TibiaDataURLs) == 0 {
+		errs = append(errs, errors.New("at least one TibiaData URL is required"))
+	}
+	for _, tibiaDataURL := range c.TibiaDataURLs {
+		if err := validateTibiaDataURL(tibiaDataURL); err != nil {
+			errs = append(errs, fmt.Errorf("TibiaData URL %q: %w", tibiaDataURL, err))
+		}
+	}
+	switch c.TibiaComMode {
+	case "off":
+	case "fallback", "primary":
+		if err := validateTibiaDataURL(c.TibiaComURL); err != nil {
+			errs = append(errs, fmt.Errorf("tibia.com URL: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown tibia.com mode %q", c.TibiaComMode))
+	}
+	if c.CacheTTL < 0 {
+		errs = append(errs, errors.New("cache TTL must not be negative"))
+	}
+	switch c.CacheStore {
+	case "memory", "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("unknown cache store %q", c.CacheStore))
+	}
+	if c.TibiaDataBreakerCooldown <= 0 {
+		errs = append(errs, errors.New("TibiaData breaker cooldown must be positive"))
+	}
+	if c.BaseURL != "" {
+		if err := validateURL(c.BaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("base URL: %w", err))
+		}
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, err := parseIPRange(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("trusted proxy: %w", err))
+		}
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("poll interval must be positive"))
+	}
+	if c.PollNameDelay < 0 {
+		errs = append(errs, errors.New("poll name delay must not be negative"))
+	}
+	if c.PollStallTimeout <= c.PollInterval {
+		errs = append(errs, errors.New("poll stall timeout must be longer than the poll interval"))
+	}
+	if c.TibiaDataReadyTimeout <= 0 {
+		errs = append(errs, errors.New("TibiaData ready timeout must be positive"))
+	}
+	if _, err := newLogger(io.Discard, c.LogFormat, c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("logging: %w", err))
+	}
+	switch c.TracingExporter {
+	case "none", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("unknown tracing exporter %q", c.TracingExporter))
+	}
+	if c.TracingEndpoint != "" {
+		if err := validateURL(c.TracingEndpoint); err != nil {
+			errs = append(errs, fmt.Errorf("tracing endpoint: %w", err))
+		}
+	}
+	for _, email := range c.AdminEmails {
+		if _, err := mail.ParseAddress(email); err != nil {
+			errs = append(errs, fmt.Errorf("admin email %q: %w", email, err))
+		}
+	}
+	if c.OIDC.IssuerURL != "" {
+		if err := validateURL(c.OIDC.IssuerURL); err != nil {
+			errs = append(errs, fmt.Errorf("OIDC issuer URL: %w", err))
+		}
+		if c.OIDC.ClientID == "" {
+			errs = append(errs, errors.New("OIDC client id is required with an OIDC issuer"))
+		}
+		if err := validateURL(c.OIDC.RedirectURL); err != nil {
+			errs = append(errs, fmt.Errorf("OIDC redirect URL: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateEmail checks the settings needed to send email.
+func (c *Config) ValidateEmail() error {
+	if _, err := mail.ParseAddress(c.EmailFrom); err != nil {
+		return fmt.Errorf("from address %q: %w", c.EmailFrom, err)
+	}
+
+	return nil
+}
+
+// ValidateServer checks the settings needed to run the web server, which
+// also sends email with links back to it.
+func (c *Config) ValidateServer() error {
+	var errs []error
+	if c.SessionStoreSecret == "" {
+		errs = append(errs, errors.New("SESSION_STORE_SECRET is required"))
+	}
+	if c.ListenAddress == "" {
+		errs = append(errs, errors.New("listen address is required"))
+	}
+	// Links in emails, like the one confirming a new email address, have to
+	// work outside the site.
+	if c.BaseURL == "" {
+		errs = append(errs, errors.New("base URL is required, links in emails are built from it"))
+	}
+
+	return errors.Join(append(errs, c.ValidateEmail())...)
+}
+
+// validateTibiaDataURL also accepts a file:// URL of a directory with
+// recorded responses, for running without network access.
+func validateTibiaDataURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "file" {
+		if u.Path == "" {
+			return errors.New("file URL needs a directory path")
+		}
+		return nil
+	}
+
+	return validateURL(s)
+}
+
+// parseIPRange reads a CIDR range, or a single address as the range of just
+// that address.
+func parseIPRange(s string) (*net.IPNet, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipRange, err := net.ParseCIDR(s)
+
+	return ipRange, err
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an absolute http or https URL")
+	}
+
+	return nil
+}
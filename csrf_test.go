@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.Use(CSRFMiddleware())
+	e.Use(CSRFContext)
+	token := func(c echo.Context) error {
+		return c.String(http.StatusOK, csrfToken(c.Request().Context()))
+	}
+	e.GET("/form", token)
+	e.POST("/form", token)
+	e.POST("/api/v1/form", token)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		path string
+		// in is where the token is sent, "form", "header" or nowhere.
+		in string
+		// token replaces the issued token when set.
+		token  string
+		bearer bool
+		want   int
+	}{
+		{name: "form field", path: "/form", in: "form", want: http.StatusOK},
+		{name: "htmx header", path: "/form", in: "header", want: http.StatusOK},
+		{name: "missing", path: "/form", want: http.StatusForbidden},
+		{name: "wrong form field", path: "/form", in: "form", token: "wrong", want: http.StatusForbidden},
+		{name: "wrong header", path: "/form", in: "header", token: "wrong", want: http.StatusForbidden},
+		{name: "API with session", path: "/api/v1/form", in: "header", token: "wrong", want: http.StatusForbidden},
+		{name: "API with bearer token", path: "/api/v1/form", bearer: true, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser := newTestBrowser(t)
+			resp, err := browser.Get(srv.URL + "/form")
+			if err != nil {
+				t.Fatal(err)
+			}
+			issued, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issued) == 0 {
+				t.Fatal("no token in the request context")
+			}
+			sent := string(issued)
+			if tt.token != "" {
+				sent = tt.token
+			}
+
+			var body io.Reader
+			if tt.in == "form" {
+				body = strings.NewReader(url.Values{"_csrf": {sent}}.Encode())
+			}
+			req, err := http.NewRequest(http.MethodPost, srv.URL+tt.path, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tt.in {
+			case "form":
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			case "header":
+				req.Header.Set(csrfHeader, sent)
+			}
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer tb_token")
+			}
+			resp, err = browser.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
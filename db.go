@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -12,12 +13,11 @@ type repositoryClient struct {
 }
 
 func RepositoryClient(filepath string) (*repositoryClient, error) {
-	db, err := sql.Open("sqlite", filepath)
+	db, err := sql.Open("sqlite", filepath+"?_pragma=foreign_keys(1)")
 	if err != nil {
 		return nil, err
 	}
-	_, err = db.Exec("CREATE TABLE IF NOT EXISTS former_names (id INTEGER PRIMARY KEY, name TEXT, notification_emails TEXT, last_checked DATETIME, last_updated_status DATETIME, status TEXT)")
-	if err != nil {
+	if err := migrate(db); err != nil {
 		return nil, err
 	}
 
@@ -28,35 +28,101 @@ func (r *repositoryClient) Close() {
 	r.Db.Close()
 }
 
-func (r *repositoryClient) GetFormerNames() ([]FormerName, error) {
-	rows, err := r.Db.Query("SELECT name, notification_emails, last_checked, last_updated_status, status FROM former_names")
+func (r *repositoryClient) GetFormerNames(ctx context.Context) ([]FormerName, error) {
+	return r.queryFormerNames(ctx, "GetFormerNames", "SELECT user_id, name, notification_emails, notify_when, last_checked, last_updated_status, status FROM former_names")
+}
+
+func (r *repositoryClient) GetUserFormerNames(ctx context.Context, userID int64) ([]FormerName, error) {
+	return r.queryFormerNames(ctx, "GetUserFormerNames", "SELECT user_id, name, notification_emails, notify_when, last_checked, last_updated_status, status FROM former_names WHERE user_id = ?", userID)
+}
+
+// GetFormerNamesByKey returns name as tracked by every user, whatever the
+// case it was given in.
+func (r *repositoryClient) GetFormerNamesByKey(ctx context.Context, name string) ([]FormerName, error) {
+	return r.queryFormerNames(ctx, "GetFormerNamesByKey", "SELECT user_id, name, notification_emails, notify_when, last_checked, last_updated_status, status FROM former_names WHERE name_key = ?", nameKey(name))
+}
+
+// GetFormerName finds the name by its key, so the case of name does not
+// matter.
+func (r *repositoryClient) GetFormerName(ctx context.Context, userID int64, name string) (*FormerName, error) {
+	formerNames, err := r.queryFormerNames(ctx, "GetFormerName", "SELECT user_id, name, notification_emails, notify_when, last_checked, last_updated_status, status FROM former_names WHERE user_id = ? AND name_key = ?", userID, nameKey(name))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	if len(formerNames) == 0 {
+		return nil, errors.New("not found")
+	}
+
+	return &formerNames[0], nil
+}
+
+func (r *repositoryClient) queryFormerNames(ctx context.Context, operation, query string, args ...any) (formerNames []FormerName, err error) {
+	ctx, span := dbSpan(ctx, operation, query)
+	defer func() { endSpan(span, err) }()
 
-	var formerNames []FormerName
+	rows, err := r.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var fn FormerName
-		err := rows.Scan(&fn.Name, &fn.NotificationEmail, &fn.LastChecked, &fn.LastUpdatedStatus, &fn.Status)
+		var userID sql.NullInt64
+		err := rows.Scan(&userID, &fn.Name, &fn.NotificationEmail, &fn.NotifyWhen, &fn.LastChecked, &fn.LastUpdatedStatus, &fn.Status)
 		if err != nil {
 			return nil, err
 		}
+		fn.UserID = userID.Int64
 		formerNames = append(formerNames, fn)
 	}
 
 	return formerNames, nil
 }
 
-func (r *repositoryClient) SaveFormerName(fn FormerName) error {
-	_, err := r.Db.Exec("INSERT OR REPLACE INTO former_names (id, name, notification_emails, last_checked, last_updated_status, status) VALUES ((SELECT id from former_names where  name = ?), ?, ?, ?, ?, ?)", fn.Name, fn.Name, fn.NotificationEmail, fn.LastChecked, fn.LastUpdatedStatus, fn.Status)
+func (r *repositoryClient) CountFormerNamesByStatus(ctx context.Context) (counts map[FormerNameStatus]int, err error) {
+	query := "SELECT status, COUNT(*) FROM former_names GROUP BY status"
+	ctx, span := dbSpan(ctx, "CountFormerNamesByStatus", query)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := r.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts = map[FormerNameStatus]int{}
+	for rows.Next() {
+		var status FormerNameStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] += count
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *repositoryClient) SaveFormerName(ctx context.Context, fn FormerName) (err error) {
+	query := "INSERT OR REPLACE INTO former_names (id, user_id, name, name_key, notification_emails, notify_when, last_checked, last_updated_status, status) VALUES ((SELECT id from former_names where user_id IS ? AND name_key = ?), ?, ?, ?, ?, ?, ?, ?, ?)"
+	ctx, span := dbSpan(ctx, "SaveFormerName", query)
+	defer func() { endSpan(span, err) }()
+
+	// Names tracked before there were accounts have no owner.
+	userID := sql.NullInt64{Int64: fn.UserID, Valid: fn.UserID != 0}
+	key := nameKey(fn.Name)
+	_, err = r.Db.ExecContext(ctx, query, userID, key, userID, fn.Name, key, fn.NotificationEmail, fn.NotifyWhen, fn.LastChecked, fn.LastUpdatedStatus, fn.Status)
 
 	return err
 }
 
-func (r *repositoryClient) DeleteFormerName(name string) error {
-	result, err := r.Db.Exec("DELETE FROM former_names where name = ?", name)
+func (r *repositoryClient) DeleteFormerName(ctx context.Context, userID int64, name string) (err error) {
+	query := "DELETE FROM former_names where user_id = ? AND name_key = ?"
+	ctx, span := dbSpan(ctx, "DeleteFormerName", query)
+	defer func() { endSpan(span, err) }()
+
+	result, err := r.Db.ExecContext(ctx, query, userID, nameKey(name))
 	if err != nil {
 		return err
 	}
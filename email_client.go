@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/resend/resend-go/v2"
-	"log"
 )
 
 type emailClient struct {
@@ -16,7 +16,7 @@ func EmailClient(resendApiKey, fromEmail string) emailClient {
 	return emailClient{client, fromEmail}
 }
 
-func (c *emailClient) NotifyUserFormerNameIsAvailable(toEmails []string, name string) {
+func (c *emailClient) NotifyUserFormerNameIsAvailable(ctx context.Context, toEmails []string, name string) error {
 	params := &resend.SendEmailRequest{
 		To:      toEmails,
 		From:    c.FromEmail,
@@ -24,8 +24,42 @@ func (c *emailClient) NotifyUserFormerNameIsAvailable(toEmails []string, name st
 		Subject: fmt.Sprintf("Tibia Buddy - %s is now available!", name),
 	}
 
-	_, err := c.Client.Emails.Send(params)
-	if err != nil {
-		log.Fatal(err)
+	_, err := c.Client.Emails.SendWithContext(ctx, params)
+	return err
+}
+
+func (c *emailClient) NotifyUserFormerNameWasTaken(ctx context.Context, toEmails []string, name string) error {
+	params := &resend.SendEmailRequest{
+		To:      toEmails,
+		From:    c.FromEmail,
+		Text:    fmt.Sprintf("%s is no longer available, a character has taken it.", name),
+		Subject: fmt.Sprintf("Tibia Buddy - %s was taken", name),
 	}
+
+	_, err := c.Client.Emails.SendWithContext(ctx, params)
+	return err
+}
+
+func (c *emailClient) NotifyUserCharacterRenamed(ctx context.Context, toEmails []string, oldName, newName string) error {
+	params := &resend.SendEmailRequest{
+		To:      toEmails,
+		From:    c.FromEmail,
+		Text:    fmt.Sprintf("%s has been renamed to %s. Tibia Buddy keeps watching it under its new name.", oldName, newName),
+		Subject: fmt.Sprintf("Tibia Buddy - %s is now %s", oldName, newName),
+	}
+
+	_, err := c.Client.Emails.SendWithContext(ctx, params)
+	return err
+}
+
+func (c *emailClient) SendEmailVerification(toEmail, link string) error {
+	params := &resend.SendEmailRequest{
+		To:      []string{toEmail},
+		From:    c.FromEmail,
+		Text:    fmt.Sprintf("Open this link to use %s for your Tibia Buddy account: %s\n\nIf you did not ask for this, ignore this email.", toEmail, link),
+		Subject: "Tibia Buddy - Confirm your new email",
+	}
+
+	_, err := c.Client.Emails.Send(params)
+	return err
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"time"
+)
+
+var (
+	errFormerNameNotFound = errors.New("former name not found")
+	errFormerNameExists   = errors.New("former name is already tracked")
+	errFormerNameRequired = errors.New("former name is required")
+	errSearchFailed       = errors.New("search failed, try again")
+	errSearchUnavailable  = errors.New("search is unavailable right now, try again later")
+	errInvalidName        = errors.New("not a valid character name")
+)
+
+// FormerNameService holds what the HTML pages and the JSON API can do with a
+// user's tracked names, so both stay in sync.
+type FormerNameService struct {
+	Db      *repositoryClient
+	Queries *sqlc.Queries
+	Api     *TibiaDataApi
+	Poller  *Poller
+}
+
+func NewFormerNameService(db *repositoryClient, t *TibiaDataApi, poller *Poller) *FormerNameService {
+	return &FormerNameService{
+		Db:      db,
+		Queries: sqlc.New(db.Db),
+		Api:     t,
+		Poller:  poller,
+	}
+}
+
+func (s *FormerNameService) List(ctx context.Context, userID int64) ([]FormerName, error) {
+	return s.Db.GetUserFormerNames(ctx, userID)
+}
+
+// tracksName says whether name is one of formerNames, whatever its case.
+func tracksName(formerNames []FormerName, name string) bool {
+	for _, formerName := range formerNames {
+		if sameName(formerName.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *FormerNameService) Get(ctx context.Context, userID int64, name string) (*FormerName, error) {
+	formerName, err := s.Db.GetFormerName(ctx, userID, name)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil, fmt.Errorf("%w: %s", errFormerNameNotFound, name)
+		}
+		return nil, err
+	}
+
+	return formerName, nil
+}
+
+// Track starts checking name for userID. The name is looked up right away
+// for its initial status, so names that no character has are tracked as
+// available from the start and the first check only notifies about a real
+// change. When the lookup fails the status is unknown until the first
+// check, which then notifies nobody about names watched for being taken.
+func (s *FormerNameService) Track(ctx context.Context, userID int64, name, notificationEmail string, notifyWhen NotifyWhen) (*FormerName, error) {
+	name = normalizeName(name)
+	if name == "" {
+		return nil, errFormerNameRequired
+	}
+	if err := validateName(name); err != nil {
+		return nil, fmt.Errorf("%s is %w", name, err)
+	}
+	if _, err := s.Get(ctx, userID, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", errFormerNameExists, name)
+	} else if !errors.Is(err, errFormerNameNotFound) {
+		return nil, err
+	}
+
+	status := unknown
+	char, err := s.Api.SearchCharacter(ctx, name)
+	switch {
+	case errors.Is(err, tibiadata.ErrInvalidName):
+		return nil, searchError(name, err)
+	case err != nil:
+		logFromContext(ctx).Warn("tracking name with unknown status, lookup failed", "name", name, "err", err)
+	default:
+		status = getNewStatus(char)
+	}
+
+	formerName := FormerName{
+		UserID:            userID,
+		Name:              name,
+		NotificationEmail: notificationEmail,
+		NotifyWhen:        notifyWhen,
+		LastChecked:       time.Now(),
+		Status:            status,
+	}
+	if err := s.Db.SaveFormerName(ctx, formerName); err != nil {
+		return nil, err
+	}
+
+	return &formerName, nil
+}
+
+// Update changes where and when notifications about name are sent, leaving
+// what is nil as it is.
+func (s *FormerNameService) Update(ctx context.Context, userID int64, name string, notificationEmail *string, notifyWhen *NotifyWhen) (*FormerName, error) {
+	formerName, err := s.Get(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if notificationEmail != nil {
+		formerName.NotificationEmail = *notificationEmail
+	}
+	if notifyWhen != nil {
+		formerName.NotifyWhen = *notifyWhen
+	}
+	if err := s.Db.SaveFormerName(ctx, *formerName); err != nil {
+		return nil, err
+	}
+
+	return formerName, nil
+}
+
+func (s *FormerNameService) Delete(ctx context.Context, userID int64, name string) error {
+	err := s.Db.DeleteFormerName(ctx, userID, name)
+	if err != nil && err.Error() == "not found" {
+		return fmt.Errorf("%w: %s", errFormerNameNotFound, name)
+	}
+
+	return err
+}
+
+func (s *FormerNameService) History(ctx context.Context, userID int64, name string) ([]sqlc.StatusChange, error) {
+	formerName, err := s.Get(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Queries.GetStatusChanges(ctx, sqlc.GetStatusChangesParams{UserID: userID, Name: formerName.Name})
+}
+
+// Recheck looks the name up right away instead of waiting for the poller,
+// bypassing the cache.
+func (s *FormerNameService) Recheck(ctx context.Context, userID int64, name string) (*FormerName, error) {
+	formerName, err := s.Get(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Poller.CheckName(withoutCache(ctx), *formerName); err != nil {
+		return nil, searchError(name, err)
+	}
+
+	return s.Get(ctx, userID, name)
+}
+
+func (s *FormerNameService) Search(ctx context.Context, name string) (*CharacterSearch, error) {
+	name = normalizeName(name)
+	if name == "" {
+		return nil, errFormerNameRequired
+	}
+	if err := validateName(name); err != nil {
+		return nil, fmt.Errorf("%s is %w", name, err)
+	}
+
+	searchCharacter, err := s.Api.SearchCharacter(ctx, name)
+	if err != nil {
+		return nil, searchError(name, err)
+	}
+
+	return searchCharacter, nil
+}
+
+// searchError turns a failed lookup of name into the error users see.
+func searchError(name string, err error) error {
+	switch {
+	case errors.Is(err, tibiadata.ErrInvalidName):
+		return fmt.Errorf("%w: %s rejected by TibiaData", errInvalidName, name)
+	case errors.Is(err, tibiadata.ErrRateLimited), errors.Is(err, tibiadata.ErrMaintenance):
+		return fmt.Errorf("%w: %w", errSearchUnavailable, err)
+	default:
+		return fmt.Errorf("%w: %w", errSearchFailed, err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFormerNameUpdate(t *testing.T) {
+	email := func(s string) *string { return &s }
+	when := func(n NotifyWhen) *NotifyWhen { return &n }
+	tests := []struct {
+		name       string
+		email      *string
+		notifyWhen *NotifyWhen
+		wantEmail  string
+		wantWhen   NotifyWhen
+	}{
+		{"email", email("new@example.com"), nil, "new@example.com", notifyWhenAvailable},
+		{"notify when", nil, when(notifyWhenTaken), "old@example.com", notifyWhenTaken},
+		{"both", email("new@example.com"), when(notifyWhenTaken), "new@example.com", notifyWhenTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			auth := NewAuthService(db.Db, nil, "")
+			user, err := auth.signUp("alice@example.com", "password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = db.SaveFormerName(ctx, FormerName{UserID: user.ID, Name: "Bubble", NotificationEmail: "old@example.com", LastChecked: time.Now(), Status: unavailable})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewFormerNameService(db, nil, nil)
+
+			if _, err := s.Update(ctx, user.ID, "bubble", tt.email, tt.notifyWhen); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Get(ctx, user.ID, "Bubble")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.NotificationEmail != tt.wantEmail || got.NotifyWhen != tt.wantWhen {
+				t.Errorf("email, notify when = %q, %s, want %q, %s", got.NotificationEmail, got.NotifyWhen, tt.wantEmail, tt.wantWhen)
+			}
+		})
+	}
+}
+
+func TestFormerNameUpdateNotFound(t *testing.T) {
+	db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := NewFormerNameService(db, nil, nil)
+
+	notifyWhen := notifyWhenTaken
+	if _, err := s.Update(context.Background(), 1, "Bubble", nil, &notifyWhen); !errors.Is(err, errFormerNameNotFound) {
+		t.Errorf("err = %v, want %v", err, errFormerNameNotFound)
+	}
+}
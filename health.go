@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HealthService answers the liveness and readiness probes. Both are public,
+// see AuthMiddleware.
+type HealthService struct {
+	Db     *sql.DB
+	Api    *TibiaDataApi
+	Poller *Poller
+	// StallTimeout is how long the poller may go without finishing a pass,
+	// TibiaDataTimeout how long TibiaData requests may keep failing.
+	StallTimeout     time.Duration
+	TibiaDataTimeout time.Duration
+
+	started time.Time
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func NewHealthService(db *sql.DB, t *TibiaDataApi, poller *Poller, stallTimeout, tibiaDataTimeout time.Duration) *HealthService {
+	return &HealthService{
+		Db:               db,
+		Api:              t,
+		Poller:           poller,
+		StallTimeout:     stallTimeout,
+		TibiaDataTimeout: tibiaDataTimeout,
+		started:          time.Now(),
+	}
+}
+
+// Healthz fails when the poller has not finished a pass within StallTimeout,
+// counting from when it started if it never finished one.
+func (h *HealthService) Healthz(c echo.Context) error {
+	return h.respond(c, map[string]error{
+		"poller": h.checkPoller(),
+	})
+}
+
+// Readyz fails when the database does not answer or TibiaData requests have
+// been failing for longer than TibiaDataTimeout.
+func (h *HealthService) Readyz(c echo.Context) error {
+	return h.respond(c, map[string]error{
+		"database":  h.checkDatabase(c.Request().Context()),
+		"tibiadata": h.checkTibiaData(),
+	})
+}
+
+func (h *HealthService) checkPoller() error {
+	state := h.Poller.State()
+	last := state.PassFinished
+	if last.IsZero() {
+		last = state.Started
+	}
+	if last.IsZero() {
+		last = h.started
+	}
+	if since := time.Since(last); since > h.StallTimeout {
+		return fmt.Errorf("no pass finished for %s", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+func (h *HealthService) checkDatabase(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return h.Db.PingContext(ctx)
+}
+
+func (h *HealthService) checkTibiaData() error {
+	success, failure := h.Api.LastResults()
+	if !failure.After(success) {
+		return nil
+	}
+	if success.Before(h.started) {
+		success = h.started
+	}
+	if since := time.Since(success); since > h.TibiaDataTimeout {
+		return fmt.Errorf("requests failing for %s", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+func (h *HealthService) respond(c echo.Context, checks map[string]error) error {
+	response := healthResponse{Status: "ok", Checks: map[string]string{}}
+	code := http.StatusOK
+	for name, err := range checks {
+		if err != nil {
+			response.Status = "fail"
+			response.Checks[name] = err.Error()
+			code = http.StatusServiceUnavailable
+		} else {
+			response.Checks[name] = "ok"
+		}
+	}
+
+	return c.JSON(code, response)
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
+	"runtime/debug"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type FormerNameStatus int
@@ -43,148 +54,573 @@ func (e FormerNameStatus) FromString(s string) FormerNameStatus {
 	}
 }
 
-type FormerName struct {
-	Name              string
-	NotificationEmail string
-	LastChecked       time.Time
-	LastUpdatedStatus *time.Time
-	Status            FormerNameStatus
+func (e FormerNameStatus) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
 }
 
-type CharacterSearch struct {
-	Found       bool
-	FormerNames []string
-	NameInput   string
-	Name        string
-	World       string
-	Trackable   bool
-	Error       error
+func (e *FormerNameStatus) UnmarshalText(text []byte) error {
+	*e = e.FromString(string(text))
+	return nil
 }
 
-type TibiaDataApi struct {
-	Url string
+// NotifyWhen says which status change of a tracked name is emailed:
+// becoming available for names held by a character, being taken for names
+// that are free and that the watcher wants to keep an eye on.
+type NotifyWhen int
+
+const (
+	notifyWhenAvailable NotifyWhen = iota
+	notifyWhenTaken
+)
+
+func (n NotifyWhen) String() string {
+	switch n {
+	case notifyWhenTaken:
+		return "taken"
+	default:
+		return "available"
+	}
 }
 
-type TibiaApiResponse struct {
-	Information TibiaApiInformation `json:"information"`
+func (n NotifyWhen) FromString(s string) NotifyWhen {
+	switch s {
+	case "taken":
+		return notifyWhenTaken
+	default:
+		return notifyWhenAvailable
+	}
 }
 
-type TibiaApiInformation struct {
-	Status TibiaApiStatus `json:"status"`
+func (n NotifyWhen) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
 }
 
-type TibiaApiStatus struct {
-	HttpCode  int `json:"http_code"`
-	ErrorCode int `json:"error"`
+func (n *NotifyWhen) UnmarshalText(text []byte) error {
+	*n = n.FromString(string(text))
+	return nil
 }
 
-type CharacterResponse struct {
-	TibiaApiResponse
-	Character CharacterWrapper `json:"character"`
+// notifies says whether going from oldStatus to newStatus is the change the
+// watcher asked to be emailed about.
+func (n NotifyWhen) notifies(oldStatus, newStatus FormerNameStatus) bool {
+	if n == notifyWhenTaken {
+		return oldStatus == available && (newStatus == unavailable || newStatus == expiring)
+	}
+
+	return newStatus == available
 }
-type CharacterWrapper struct {
-	Character Character `json:"character"`
+
+type FormerName struct {
+	UserID            int64            `json:"-"`
+	Name              string           `json:"name"`
+	NotificationEmail string           `json:"notification_email"`
+	NotifyWhen        NotifyWhen       `json:"notify_when"`
+	LastChecked       time.Time        `json:"last_checked"`
+	LastUpdatedStatus *time.Time       `json:"last_updated_status"`
+	Status            FormerNameStatus `json:"status"`
 }
 
-type Character struct {
+type CharacterSearch struct {
+	Found       bool     `json:"found"`
+	FormerNames []string `json:"former_names"`
+	NameInput   string   `json:"name_input"`
 	Name        string   `json:"name"`
 	World       string   `json:"world"`
-	FormerNames []string `json:"former_names"`
+	Trackable   bool     `json:"trackable"`
+	Error       error    `json:"-"`
+	// Mirror is the TibiaData instance that answered, Degraded whether it
+	// had been failing and Cached whether the answer came from the cache
+	// without asking it.
+	Mirror   string `json:"-"`
+	Degraded bool   `json:"-"`
+	Cached   bool   `json:"-"`
 }
 
-func (t *TibiaDataApi) SearchCharacter(name string) (*CharacterSearch, error) {
-	resp, err := http.Get(t.Url + "/v4/character/" + name)
-	if err != nil {
-		return nil, err
+// NameStatus is the status the searched name has according to the search:
+// available when no character has it, unavailable when it is the current name
+// of the character found and expiring when it is one of its former names.
+func (c *CharacterSearch) NameStatus() FormerNameStatus {
+	switch {
+	case !c.Found:
+		return available
+	case sameName(c.Name, c.NameInput):
+		return unavailable
+	case c.Trackable:
+		return expiring
+	default:
+		return unknown
 	}
-	defer resp.Body.Close()
+}
 
-	var j CharacterResponse
-	err = json.NewDecoder(resp.Body).Decode(&j)
+// TibiaDataApi looks characters up for the poller and the search and keeps
+// track of whether TibiaData is answering. Mirrors, which include tibia.com
+// unless it is turned off, are tried in order, the first one whose breaker
+// lets the request through and that answers wins.
+type TibiaDataApi struct {
+	Mirrors []*tibiaDataMirror
+	// Cache is nil when caching is turned off.
+	Cache *responseCache
+
+	// Unix nanoseconds of the last lookup that got an answer from any mirror
+	// and of the last one that got none, for the readiness check.
+	lastSuccess atomic.Int64
+	lastFailure atomic.Int64
+}
 
+var errNoMirror = errors.New("no TibiaData mirror available")
+
+// NewTibiaDataApi sets up the configured TibiaData mirrors and, depending
+// on the tibia.com mode, the website before or after them. Lookups go
+// through cache unless it is nil.
+func NewTibiaDataApi(config *Config, cache *responseCache) (*TibiaDataApi, error) {
+	cooldown := time.Duration(config.TibiaDataBreakerCooldown)
+	t := &TibiaDataApi{Cache: cache}
+	for _, rawURL := range config.TibiaDataURLs {
+		mirror, err := newTibiaDataMirror(rawURL, cooldown, cache)
+		if err != nil {
+			return nil, err
+		}
+		t.Mirrors = append(t.Mirrors, mirror)
+	}
+
+	if config.TibiaComMode != "off" {
+		mirror, err := newTibiaComMirror(config.TibiaComURL, cooldown, cache)
+		if err != nil {
+			return nil, err
+		}
+		if config.TibiaComMode == "primary" {
+			t.Mirrors = append([]*tibiaDataMirror{mirror}, t.Mirrors...)
+		} else {
+			t.Mirrors = append(t.Mirrors, mirror)
+		}
+	}
+
+	return t, nil
+}
+
+// MirrorStatuses returns the breaker state of every mirror.
+func (t *TibiaDataApi) MirrorStatuses() []MirrorStatus {
+	statuses := make([]MirrorStatus, len(t.Mirrors))
+	for i, mirror := range t.Mirrors {
+		statuses[i] = mirror.Status()
+	}
+
+	return statuses
+}
+
+// LastResults returns when a request last got an answer and when one last
+// failed, zero if that has not happened yet.
+func (t *TibiaDataApi) LastResults() (success, failure time.Time) {
+	if ns := t.lastSuccess.Load(); ns != 0 {
+		success = time.Unix(0, ns)
+	}
+	if ns := t.lastFailure.Load(); ns != 0 {
+		failure = time.Unix(0, ns)
+	}
+
+	return success, failure
+}
+
+func (t *TibiaDataApi) recordResult(err error) {
 	if err != nil {
-		return nil, err
+		t.lastFailure.Store(time.Now().UnixNano())
+	} else {
+		t.lastSuccess.Store(time.Now().UnixNano())
+	}
+}
+
+func (t *TibiaDataApi) SearchCharacter(ctx context.Context, name string) (_ *CharacterSearch, err error) {
+	// Normalized names share cache entries whatever case they were typed in.
+	name = normalizeName(name)
+	ctx, span := tracer.Start(ctx, "TibiaDataApi.SearchCharacter", trace.WithAttributes(attribute.String("tibia.name", name)))
+	defer func() { endSpan(span, err) }()
+
+	char, err := t.search(ctx, name, t.Mirrors)
+	if char != nil {
+		span.SetAttributes(attribute.String("tibiadata.mirror", char.Mirror), attribute.Bool("tibiadata.degraded", char.Degraded), attribute.Bool("tibiadata.cached", char.Cached))
+	}
+	// Cached answers say nothing about whether TibiaData is reachable.
+	if char == nil || !char.Cached {
+		t.recordResult(err)
 	}
 
-	found := true
-	if j.Information.Status.ErrorCode == 20001 {
-		found = false
+	return char, err
+}
+
+// CrossCheck asks another mirror about the character of c, healthy mirrors
+// first and bypassing the cache. It is meant for answers from a degraded
+// mirror that would make a name available, and fails if no other mirror
+// answers.
+func (t *TibiaDataApi) CrossCheck(ctx context.Context, c *CharacterSearch) (_ *CharacterSearch, err error) {
+	ctx, span := tracer.Start(ctx, "TibiaDataApi.CrossCheck", trace.WithAttributes(
+		attribute.String("tibia.name", c.NameInput),
+		attribute.String("tibiadata.mirror", c.Mirror),
+	))
+	defer func() { endSpan(span, err) }()
+
+	var healthy, degraded []*tibiaDataMirror
+	for _, mirror := range t.Mirrors {
+		switch {
+		case mirror.URL == c.Mirror:
+		case mirror.Status().Failures == 0:
+			healthy = append(healthy, mirror)
+		default:
+			degraded = append(degraded, mirror)
+		}
+	}
+
+	char, err := t.search(withoutCache(ctx), c.NameInput, append(healthy, degraded...))
+	if errors.Is(err, errNoMirror) {
+		return nil, fmt.Errorf("can not cross-check answer of degraded mirror %s: %w", c.Mirror, err)
 	}
 
+	return char, err
+}
+
+// search asks the mirrors in turn until one answers. A mirror that fails is
+// skipped, an error is only returned if no mirror answered.
+func (t *TibiaDataApi) search(ctx context.Context, name string, mirrors []*tibiaDataMirror) (*CharacterSearch, error) {
+	lastErr := errNoMirror
+	for _, mirror := range mirrors {
+		allowed, degraded := mirror.allow()
+		if !allowed {
+			continue
+		}
+
+		start := time.Now()
+		mirrorCtx, cached := withCacheHit(ctx)
+		info, err := mirror.Source.Character(mirrorCtx, name)
+		if cached.Load() {
+			mirror.skip()
+		} else {
+			code, apiError := "200", "0"
+			var statusError *tibiadata.StatusError
+			switch {
+			case errors.As(err, &statusError):
+				code, apiError = strconv.Itoa(statusError.HTTPCode), strconv.Itoa(statusError.Code)
+			case err != nil:
+				code, apiError = "error", ""
+			}
+			tibiaDataRequestDuration.WithLabelValues(mirror.URL, code, apiError).Observe(time.Since(start).Seconds())
+			mirror.record(err)
+		}
+
+		if tibiadata.IsNotFound(err) {
+			return &CharacterSearch{NameInput: name, Mirror: mirror.URL, Degraded: degraded, Cached: cached.Load()}, nil
+		}
+		if mirrorFailed(err) {
+			logFromContext(ctx).Warn("TibiaData mirror failed", "mirror", mirror.URL, "err", err)
+			lastErr = fmt.Errorf("%s: %w", mirror.URL, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		char := newCharacterSearch(name, info, mirror.URL, degraded)
+		char.Cached = cached.Load()
+		return char, nil
+	}
+
+	return nil, lastErr
+}
+
+func newCharacterSearch(name string, info *tibiadata.CharacterInfo, mirror string, degraded bool) *CharacterSearch {
 	trackable := false
-	formerNames := j.Character.Character.FormerNames
+	formerNames := info.Character.FormerNames
 	for _, formerName := range formerNames {
-		if strings.ToLower(formerName) == strings.ToLower(name) {
+		if sameName(formerName, name) {
 			trackable = true
 			break
 		}
 	}
 
 	return &CharacterSearch{
-		Found:       found,
+		Found:       true,
 		NameInput:   name,
-		Name:        j.Character.Character.Name,
-		FormerNames: j.Character.Character.FormerNames,
-		World:       j.Character.Character.World,
+		Name:        info.Character.Name,
+		FormerNames: formerNames,
+		World:       info.Character.World,
 		Trackable:   trackable,
-	}, nil
+		Mirror:      mirror,
+		Degraded:    degraded,
+	}
 }
 
-func getNewStatus(name string, c *CharacterSearch) FormerNameStatus {
-	if !c.Found {
-		return available
+func getNewStatus(c *CharacterSearch) FormerNameStatus {
+	status := c.NameStatus()
+	if status == unknown {
+		slog.Warn("name is neither held nor a former name of the character found", "name", c.NameInput, "character", c.Name)
 	}
 
-	if strings.ToLower(c.Name) == strings.ToLower(name) {
-		return unavailable
+	return status
+}
+
+type PollerState struct {
+	Running      bool
+	Passes       int
+	CurrentName  string
+	Started      time.Time
+	PassStarted  time.Time
+	PassFinished time.Time
+	LastError    string
+}
+
+// Poller periodically checks the status of every tracked name and emails the
+// watchers of names that became available, or were taken for names tracked
+// with notifyWhenTaken. It also follows watched characters across renames.
+type Poller struct {
+	Db       *repositoryClient
+	Queries  *sqlc.Queries
+	Api      *TibiaDataApi
+	Email    *emailClient
+	Interval time.Duration
+	// NameDelay spaces out the requests for the names within a pass.
+	NameDelay time.Duration
+
+	recheck chan struct{}
+	mu      sync.Mutex
+	state   PollerState
+}
+
+func NewPoller(db *repositoryClient, t *TibiaDataApi, e *emailClient) *Poller {
+	return &Poller{
+		Db:        db,
+		Queries:   sqlc.New(db.Db),
+		Api:       t,
+		Email:     e,
+		Interval:  5 * time.Minute,
+		NameDelay: 1 * time.Second,
+		recheck:   make(chan struct{}, 1),
 	}
+}
+
+func (p *Poller) Run() {
+	slog.Info("poller started", "interval", p.Interval)
+	p.updateState(func(s *PollerState) { s.Started = time.Now() })
+	for {
+		p.pass()
 
-	for _, charFormerName := range c.FormerNames {
-		if strings.ToLower(charFormerName) == strings.ToLower(name) {
-			return expiring
+		select {
+		case <-time.After(p.Interval):
+		case <-p.recheck:
 		}
 	}
-	fmt.Println("not sure what is happening", c)
+}
 
-	return unknown
+// Recheck starts the next pass right away instead of waiting for the
+// interval, or right after the current one if a pass is running.
+func (p *Poller) Recheck() {
+	select {
+	case p.recheck <- struct{}{}:
+	default:
+	}
 }
 
-func runBackground(db *repositoryClient, t *TibiaDataApi, e *emailClient) {
-	fmt.Println("running background")
-	for {
-		fmt.Println("started...")
-		formerNames, err := db.GetFormerNames()
+func (p *Poller) State() PollerState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+func (p *Poller) updateState(update func(s *PollerState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.state)
+}
+
+// pass checks every tracked name once. A pass that fails or panics is not
+// counted as finished, so the liveness check notices a poller that keeps
+// failing while the process stays up.
+func (p *Poller) pass() (err error) {
+	ctx, span := tracer.Start(context.Background(), "Poller.pass")
+	logger := slog.Default().With("pass_id", newCorrelationID())
+	ctx = contextWithLogger(ctx, logger)
+	logger.Info("pass started")
+	p.updateState(func(s *PollerState) {
+		s.Running = true
+		s.PassStarted = time.Now()
+	})
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pass panicked: %v", r)
+			logger.Error("pass panicked", "panic", r, "stack", string(debug.Stack()))
+		}
+		if err != nil {
+			p.updateState(func(s *PollerState) {
+				s.Running = false
+				s.CurrentName = ""
+				s.LastError = err.Error()
+			})
+		}
+		endSpan(span, err)
+	}()
+
+	formerNames, err := p.Db.GetFormerNames(ctx)
+	if err != nil {
+		logger.Error("failed to list names", "err", err)
+		return err
+	}
+	failed := 0
+	for _, name := range formerNames {
+		if err := p.CheckName(ctx, name); err != nil {
+			failed++
+		}
+		time.Sleep(p.NameDelay)
+	}
+
+	characters, err := p.Queries.ListWatchedCharacters(ctx)
+	if err != nil {
+		logger.Error("failed to list watched characters", "err", err)
+		return err
+	}
+	for _, character := range characters {
+		if err := p.CheckCharacter(ctx, character); err != nil {
+			failed++
+		}
+		time.Sleep(p.NameDelay)
+	}
+
+	p.updateState(func(s *PollerState) {
+		s.Running = false
+		s.Passes++
+		s.CurrentName = ""
+		s.PassFinished = time.Now()
+		pollPassDuration.Observe(s.PassFinished.Sub(s.PassStarted).Seconds())
+		pollLastPassFinished.Set(float64(s.PassFinished.Unix()))
+	})
+	logger.Info("pass finished", "names", len(formerNames), "characters", len(characters), "failed", failed)
+
+	return nil
+}
+
+// CheckName looks up the current status of name and saves it. Everything it
+// logs carries a check id on top of the attributes of the logger in ctx.
+func (p *Poller) CheckName(ctx context.Context, name FormerName) (err error) {
+	ctx, span := tracer.Start(ctx, "Poller.CheckName", trace.WithAttributes(attribute.String("tibia.name", name.Name)))
+	defer func() { endSpan(span, err) }()
+	logger := logFromContext(ctx).With("check_id", newCorrelationID(), "name", name.Name)
+	ctx = contextWithLogger(ctx, logger)
+	logger.Debug("checking name")
+	p.updateState(func(s *PollerState) { s.CurrentName = name.Name })
+
+	// The status is left as it is when the lookup fails.
+	fail := func(err error) error {
+		if isUpstreamError(err) {
+			checksTotal.WithLabelValues("skipped").Inc()
+			logger.Warn("check skipped, no answer from TibiaData", "err", err)
+		} else {
+			checksTotal.WithLabelValues("error").Inc()
+			logger.Error("check failed", "err", err)
+		}
+		p.updateState(func(s *PollerState) { s.LastError = err.Error() })
+		return err
+	}
+
+	char, err := p.Api.SearchCharacter(ctx, name.Name)
+	if err != nil {
+		return fail(err)
+	}
+
+	// A cached answer can be as old as the cache TTL, so a name only becomes
+	// available on a fresh one.
+	if !char.Found && name.Status != available && p.Api.Cache != nil && !bypassesCache(ctx) {
+		char, err = p.Api.SearchCharacter(withoutCache(ctx), name.Name)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	// A degraded mirror may answer "not found" for characters that exist,
+	// so only another mirror can make a name available.
+	if !char.Found && char.Degraded {
+		char, err = p.Api.CrossCheck(ctx, char)
 		if err != nil {
-			panic(err)
+			return fail(err)
+		}
+	}
 
+	oldStatus := name.Status
+	newStatus := getNewStatus(char)
+	logger.Info("checked name", "old_status", oldStatus, "new_status", newStatus)
+	checksTotal.WithLabelValues(newStatus.String()).Inc()
+	if oldStatus != newStatus {
+		statusTransitionsTotal.WithLabelValues(oldStatus.String(), newStatus.String()).Inc()
+		if name.NotifyWhen.notifies(oldStatus, newStatus) {
+			p.notify(ctx, strings.Split(name.NotificationEmail, ","), name.Name, name.NotifyWhen)
 		}
-		for _, name := range formerNames {
-			fmt.Println("checking name", name.Name)
-			char, err := t.SearchCharacter(name.Name)
+		now := time.Now()
+		name.LastUpdatedStatus = &now
+
+		// The history is shown to the owner, names without one have none.
+		if name.UserID != 0 {
+			err := p.Queries.CreateStatusChange(ctx, sqlc.CreateStatusChangeParams{
+				UserID:    name.UserID,
+				Name:      name.Name,
+				OldStatus: oldStatus.String(),
+				NewStatus: newStatus.String(),
+				Created:   now.UTC(),
+			})
 			if err != nil {
-				fmt.Println(err)
-				time.Sleep(1 * time.Second)
-				continue
+				logger.Error("failed to record status change", "err", err)
 			}
+		}
+	}
 
-			oldStatus := name.Status
-			newStatus := getNewStatus(name.Name, char)
-			fmt.Printf("checked name %s old_status=%s new_status=%s\n", name.Name, oldStatus, newStatus)
-			if oldStatus != newStatus {
-				if newStatus == available {
-					e.NotifyUserFormerNameIsAvailable(strings.Split(name.NotificationEmail, ","), name.Name)
-				}
-				now := time.Now()
-				name.LastUpdatedStatus = &now
-			}
+	name.Status = newStatus
+	name.LastChecked = time.Now()
+
+	if err := p.Db.SaveFormerName(ctx, name); err != nil {
+		logger.Error("failed to save status", "err", err)
+		return err
+	}
 
-			name.Status = newStatus
-			name.LastChecked = time.Now()
+	return nil
+}
 
-			db.SaveFormerName(name)
-			time.Sleep(1 * time.Second)
+func (p *Poller) notify(ctx context.Context, emails []string, name string, when NotifyWhen) {
+	p.deliver(ctx, emails, name, func(ctx context.Context) error {
+		if when == notifyWhenTaken {
+			return p.Email.NotifyUserFormerNameWasTaken(ctx, emails, name)
 		}
+		return p.Email.NotifyUserFormerNameIsAvailable(ctx, emails, name)
+	})
+}
 
-		time.Sleep(5 * time.Minute)
+// deliver sends a notification about name to emails with send, counts it
+// and records it with its error, if any.
+func (p *Poller) deliver(ctx context.Context, emails []string, name string, send func(ctx context.Context) error) {
+	ctx, span := tracer.Start(ctx, "Poller.notify", trace.WithAttributes(
+		attribute.String("tibia.name", name),
+		attribute.String("notification.channel", "email"),
+		attribute.Int("notification.recipients", len(emails)),
+	))
+	logger := logFromContext(ctx)
+	sendErr := send(ctx)
+	endSpan(span, sendErr)
+	if sendErr != nil {
+		notificationsTotal.WithLabelValues("email", "failed").Inc()
+		logger.Error("failed to notify", "name", name, "emails", strings.Join(emails, ","), "err", sendErr)
+	} else {
+		notificationsTotal.WithLabelValues("email", "sent").Inc()
+		logger.Info("notified", "name", name, "emails", strings.Join(emails, ","))
+	}
+
+	err := p.Queries.CreateNotification(ctx, sqlc.CreateNotificationParams{
+		FormerName: name,
+		Emails:     strings.Join(emails, ","),
+		Error:      errorString(sendErr),
+		Created:    time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Error("failed to record notification", "err", err)
 	}
 }
+
+func errorString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: err.Error(), Valid: true}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestNotifyWhenNotifies(t *testing.T) {
+	tests := []struct {
+		when                 NotifyWhen
+		oldStatus, newStatus FormerNameStatus
+		want                 bool
+	}{
+		{notifyWhenAvailable, unavailable, available, true},
+		{notifyWhenAvailable, expiring, available, true},
+		{notifyWhenAvailable, unknown, available, true},
+		{notifyWhenAvailable, unavailable, expiring, false},
+		{notifyWhenAvailable, available, unavailable, false},
+		{notifyWhenAvailable, available, expiring, false},
+		{notifyWhenAvailable, unavailable, unknown, false},
+
+		{notifyWhenTaken, available, unavailable, true},
+		{notifyWhenTaken, available, expiring, true},
+		{notifyWhenTaken, available, unknown, false},
+		{notifyWhenTaken, unknown, unavailable, false},
+		{notifyWhenTaken, expiring, unavailable, false},
+		{notifyWhenTaken, unavailable, available, false},
+		{notifyWhenTaken, expiring, available, false},
+	}
+	for _, tt := range tests {
+		if got := tt.when.notifies(tt.oldStatus, tt.newStatus); got != tt.want {
+			t.Errorf("%s.notifies(%s, %s) = %v, want %v", tt.when, tt.oldStatus, tt.newStatus, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyWhenText(t *testing.T) {
+	tests := []struct {
+		text string
+		want NotifyWhen
+	}{
+		{"available", notifyWhenAvailable},
+		{"taken", notifyWhenTaken},
+		{"", notifyWhenAvailable},
+		{"something else", notifyWhenAvailable},
+	}
+	for _, tt := range tests {
+		var got NotifyWhen
+		if err := got.UnmarshalText([]byte(tt.text)); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("UnmarshalText(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+		text, err := got.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.FromString(string(text)) != got {
+			t.Errorf("%s does not survive MarshalText", got)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redacted = "[REDACTED]"
+
+var emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+
+// Attributes whose key contains one of these are never written.
+var secretKeys = []string{"password", "secret", "token", "authorization", "cookie"}
+
+type loggerContextKey struct{}
+
+// newLogger returns a logger writing format ("text" or "json") at level and
+// above. Secret attributes are masked and email addresses in messages and
+// values are shortened to their first letter and domain. Durations are
+// written like "1.5s" in both formats.
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: logLevel, ReplaceAttr: replaceAttr}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, secretKey := range secretKeys {
+		if strings.Contains(key, secretKey) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactEmails(a.Value.String()))
+	case slog.KindDuration:
+		return slog.String(a.Key, a.Value.Duration().String())
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactEmails(err.Error()))
+		}
+	}
+
+	return a
+}
+
+func redactEmails(s string) string {
+	return emailPattern.ReplaceAllString(s, "$1***@$2")
+}
+
+// logFromContext returns the logger of the request ctx belongs to, which
+// adds its request id, or the default logger outside of requests.
+func logFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+func contextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// RequestLogger logs every request once it is handled. It has to run after
+// the RequestID middleware, whose id it adds to everything logged for the
+// request. Query strings are left out as they can carry tokens.
+func RequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+		logger := slog.Default().With("request_id", c.Response().Header().Get(echo.HeaderXRequestID))
+		if spanContext := trace.SpanContextFromContext(req.Context()); spanContext.HasTraceID() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+		c.SetRequest(req.WithContext(contextWithLogger(req.Context(), logger)))
+
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.RealIP()),
+		}
+		if user := contextUser(c.Request().Context()); user != nil {
+			attrs = append(attrs, slog.Int64("user_id", user.ID))
+		}
+		logger.LogAttrs(req.Context(), level, "request", attrs...)
+
+		return nil
+	}
+}
+
+// newCorrelationID returns a short random id that ties together the log
+// lines of one poller pass or one name check.
+func newCorrelationID() string {
+	id, err := randomToken(6)
+	if err != nil {
+		return "unknown"
+	}
+
+	return id
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/labstack/echo/v4/middleware"
-	"github.com/labstack/gommon/log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"rustydoggobytes/tibiabuddy/sqlc"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/sessions"
-	"github.com/joho/godotenv"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"golang.org/x/time/rate"
 )
 
 //go:embed static/*
@@ -24,103 +32,311 @@ var contentHandler = echo.WrapHandler(http.FileServer(http.FS(embeddedFiles)))
 var contentRewrite = middleware.Rewrite(map[string]string{"/*": "/static/$1"})
 
 func main() {
-	err := godotenv.Load()
+	config, args, err := LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(2)
+	}
+	logger, _ := newLogger(os.Stderr, config.LogFormat, config.LogLevel)
+	slog.SetDefault(logger)
+	shutdownTracing, err := setupTracing(context.Background(), config.TracingExporter, config.TracingEndpoint)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tracing:", err)
+		os.Exit(2)
+	}
+
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	err = runCommand(config, command, args)
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("failed to flush traces", "err", err)
+	}
 	if err != nil {
-		log.Error("Error loading .env file")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(config *Config) error {
+	if err := config.ValidateServer(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	db, err := RepositoryClient("data/tibiabuddy.db")
+	db, err := RepositoryClient(config.DatabasePath)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	emailClient := EmailClient(os.Getenv("RESEND_API_TOKEN"), os.Getenv("EMAIL"))
-	authService := NewAuthService(db.Db)
-	cookieStore := sessions.NewCookieStore([]byte(os.Getenv("SESSION_STORE_SECRET")))
+	emailClient := EmailClient(config.ResendAPIToken, config.EmailFrom)
+	authService := NewAuthService(db.Db, &emailClient, config.BaseURL)
+	authService.AdminEmails = config.AdminEmails
+	if err := authService.promoteAdmins(); err != nil {
+		return err
+	}
+	if config.OIDC.IssuerURL != "" {
+		authService.OIDC, err = NewOIDCProvider(authService.Ctx, config.OIDC.ProviderName, config.OIDC.IssuerURL, config.OIDC.ClientID, config.OIDC.ClientSecret, config.OIDC.RedirectURL)
+		if err != nil {
+			slog.Error("OIDC provider discovery failed, sign in with OIDC is disabled", "issuer", config.OIDC.IssuerURL, "err", err)
+		}
+	}
+	cookieStore := sessions.NewCookieStore([]byte(config.SessionStoreSecret))
+	cookieStore.Options.HttpOnly = true
+	cookieStore.Options.SameSite = http.SameSiteLaxMode
 
-	t := TibiaDataApi{
-		Url: "https://tibiadata.rustydoggobytes.com",
+	t, err := NewTibiaDataApi(config, newResponseCache(config, db))
+	if err != nil {
+		return err
 	}
-	go runBackground(db, &t, &emailClient)
+	prometheus.MustRegister(trackedNamesCollector{Db: db})
+	poller := NewPoller(db, t, &emailClient)
+	poller.Interval = time.Duration(config.PollInterval)
+	poller.NameDelay = time.Duration(config.PollNameDelay)
+	go poller.Run()
+
+	healthService := NewHealthService(db.Db, t, poller, time.Duration(config.PollStallTimeout), time.Duration(config.TibiaDataReadyTimeout))
+	adminService := NewAdminService(db, poller)
+	formerNameService := NewFormerNameService(db, t, poller)
+	watchedCharacterService := NewWatchedCharacterService(db, t)
 
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = ErrorHandler
+	e.IPExtractor = ipExtractor(config.TrustedProxies)
+	e.Use(otelecho.Middleware(serviceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		path := c.Request().URL.Path
+		return path == "/metrics" || path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/static/")
+	})))
+	e.Use(middleware.RequestID())
+	e.Use(MetricsMiddleware)
+	e.Use(RequestLogger)
 	e.Static("/static", "static")
 	e.Use(session.Middleware(cookieStore))
+	e.Use(CSRFMiddleware())
+	e.Use(CSRFContext)
 	e.Use(authService.AuthMiddleware)
 
 	e.GET("/*", contentHandler, contentRewrite)
 
 	e.POST("/former-name/search", func(c echo.Context) error {
 		formerName := c.FormValue("former-name")
-		searchCharacter, err := t.SearchCharacter(formerName)
+		searchCharacter, err := formerNameService.Search(c.Request().Context(), formerName)
 
-		if err != nil {
+		if errors.Is(err, errInvalidName) {
+			searchCharacter = &CharacterSearch{Error: err}
+		} else if errors.Is(err, errSearchUnavailable) {
+			searchCharacter = &CharacterSearch{Error: errors.New("TibiaData is unavailable right now. Try again later.")}
+		} else if err != nil {
 			errorMsg := "Search failed. Try again."
 			searchCharacter = &CharacterSearch{Error: errors.New(errorMsg)}
 		}
-		if !searchCharacter.Found {
-			searchCharacter = &CharacterSearch{Error: errors.New(fmt.Sprintf("Character Not Found - %s", formerName))}
-		}
 
-		formerNames, _ := db.GetFormerNames()
-		component := layout(index(formerNames, searchCharacter, nil), true)
-		return component.Render(c.Request().Context(), c.Response())
+		return renderIndex(c, formerNameService, watchedCharacterService, searchCharacter, nil)
 	})
 
 	e.GET("/", func(c echo.Context) error {
-		formerNames, _ := db.GetFormerNames()
-		component := layout(index(formerNames, nil, nil), true)
-		return component.Render(c.Request().Context(), c.Response())
+		return renderIndex(c, formerNameService, watchedCharacterService, nil, nil)
 	})
 
 	e.DELETE("/former-names/:name", func(c echo.Context) error {
 		formerName := c.Param("name")
-		err := db.DeleteFormerName(formerName)
+		err := formerNameService.Delete(c.Request().Context(), contextUser(c.Request().Context()).ID, formerName)
 
 		if err != nil {
-			if err.Error() == "not found" {
+			if errors.Is(err, errFormerNameNotFound) {
 				err = errors.New(fmt.Sprintf("Former Name %s not found", formerName))
+			} else {
+				return err
 			}
 		}
 
-		formerNames, _ := db.GetFormerNames()
-		component := layout(index(formerNames, nil, nil), true)
-		return component.Render(c.Request().Context(), c.Response())
+		return renderIndex(c, formerNameService, watchedCharacterService, nil, err)
 	})
 
 	e.POST("/former-names", func(c echo.Context) error {
 		formerName := c.FormValue("former-name")
 		notificationEmail := c.FormValue("notification-email")
-		var status FormerNameStatus
-		status = status.FromString(c.FormValue("status"))
-
-		if err := db.SaveFormerName(FormerName{Name: formerName, NotificationEmail: notificationEmail, LastChecked: time.Now(), Status: status}); err != nil {
-			e.Logger.Fatal(err)
+		var notifyWhen NotifyWhen
+		notifyWhen = notifyWhen.FromString(c.FormValue("notify-when"))
+		_, err := formerNameService.Track(c.Request().Context(), contextUser(c.Request().Context()).ID, formerName, notificationEmail, notifyWhen)
+		if err != nil && !errors.Is(err, errFormerNameExists) && !errors.Is(err, errFormerNameRequired) && !errors.Is(err, errInvalidName) {
+			return err
 		}
-		formerNames, _ := db.GetFormerNames()
-		component := layout(index(formerNames, nil, nil), true)
-		return component.Render(c.Request().Context(), c.Response())
+
+		return renderIndex(c, formerNameService, watchedCharacterService, nil, err)
 	})
 
-	e.POST("/send-email", func(c echo.Context) error {
-		emails := strings.Split(c.FormValue("emails"), ",")
-		formerName := c.FormValue("name")
+	e.POST("/watched-characters", func(c echo.Context) error {
+		name := c.FormValue("character-name")
+		notificationEmail := c.FormValue("notification-email")
+
+		_, err := watchedCharacterService.Watch(c.Request().Context(), contextUser(c.Request().Context()).ID, name, notificationEmail)
+		if errors.Is(err, errSearchUnavailable) {
+			err = errors.New("TibiaData is unavailable right now. Try again later.")
+		} else if errors.Is(err, errSearchFailed) {
+			err = errors.New("Search failed. Try again.")
+		} else if err != nil && !errors.Is(err, errWatchedCharacterExists) && !errors.Is(err, errFormerNameRequired) && !errors.Is(err, errCharacterNotFound) && !errors.Is(err, errInvalidName) {
+			return err
+		}
 
-		emailClient.NotifyUserFormerNameIsAvailable(emails, formerName)
+		return renderIndex(c, formerNameService, watchedCharacterService, nil, err)
+	})
 
-		formerNames, _ := db.GetFormerNames()
-		component := layout(index(formerNames, nil, nil), true)
-		return component.Render(c.Request().Context(), c.Response())
+	e.DELETE("/watched-characters/:name", func(c echo.Context) error {
+		err := watchedCharacterService.Unwatch(c.Request().Context(), contextUser(c.Request().Context()).ID, c.Param("name"))
+		if err != nil && !errors.Is(err, errWatchedCharacterNotFound) {
+			return err
+		}
+
+		return renderIndex(c, formerNameService, watchedCharacterService, nil, err)
 	})
 
+	api := API{FormerNames: formerNameService, Characters: watchedCharacterService}
+	api.Register(e.Group("/api/v1"))
+	e.GET("/api/openapi.json", OpenAPISpec)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()), AdminMiddleware)
+	e.GET("/healthz", healthService.Healthz)
+	e.GET("/readyz", healthService.Readyz)
+
+	admin := e.Group("/admin", AdminMiddleware)
+	admin.GET("", adminService.Console)
+	admin.POST("/users/:id/disable", adminService.DisableUser)
+	admin.POST("/users/:id/enable", adminService.EnableUser)
+	admin.POST("/former-names/:name/recheck", adminService.RecheckName)
+	admin.POST("/poller/recheck", adminService.RecheckAll)
+	admin.POST("/send-email", adminService.SendTestEmail)
+
 	e.GET("/signup", SignUpPage)
-	e.GET("/signin", SignInPage)
+	e.GET("/signin", authService.SignInPage)
+
+	authRateLimiter := AuthRateLimiter()
+	e.POST("/signup", authService.SignUp, authRateLimiter)
+	e.POST("/signin", authService.SignIn, authRateLimiter)
+	e.POST("/signout", authService.SignOut)
+
+	e.GET("/signin/oidc", authService.OIDCSignIn)
+	e.GET("/signin/oidc/callback", authService.OIDCCallback)
+	e.GET("/signin/2fa", TwoFactorSignInPage)
+	e.POST("/signin/2fa", authService.TwoFactorSignIn, authRateLimiter)
+	e.GET("/account", authService.AccountPage)
+	e.POST("/account/password", authService.ChangePassword)
+	e.POST("/account/email", authService.ChangeEmail)
+	e.GET("/account/email/verify", authService.VerifyEmail)
+	e.POST("/account/delete", authService.DeleteAccount)
+	e.GET("/account/tokens", authService.APITokensPage)
+	e.POST("/account/tokens", authService.CreateAPIToken)
+	e.POST("/account/tokens/:id/revoke", authService.RevokeAPIToken)
+	e.GET("/account/2fa", authService.TwoFactorPage)
+	e.POST("/account/2fa/enable", authService.EnableTwoFactor)
+	e.POST("/account/2fa/disable", authService.DisableTwoFactor)
+
+	// Stop on SIGINT or SIGTERM so that main can flush buffered traces.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		slog.Info("shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := e.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to shut down", "err", err)
+		}
+	}()
+
+	if config.MetricsListenAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", promhttp.Handler())
+		metricsServer := &http.Server{Addr: config.MetricsListenAddress, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			metricsServer.Close()
+		}()
+		go func() {
+			slog.Info("serving metrics", "address", config.MetricsListenAddress)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("metrics server failed", "err", err)
+			}
+		}()
+	}
+
+	slog.Info("listening", "address", config.ListenAddress)
+	if err := e.Start(config.ListenAddress); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ipExtractor finds the client IP for rate limits and sign in lockouts. The
+// X-Forwarded-For header is only read when it was set by one of proxies,
+// anybody else could send a new address with every request.
+func ipExtractor(proxies []string) echo.IPExtractor {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		ipRange, _ := parseIPRange(proxy)
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+func renderIndex(c echo.Context, s *FormerNameService, w *WatchedCharacterService, searchCharacter *CharacterSearch, err error) error {
+	formerNames, listErr := s.List(c.Request().Context(), contextUser(c.Request().Context()).ID)
+	if listErr != nil {
+		return listErr
+	}
+	watchedCharacters, listErr := w.List(c.Request().Context(), contextUser(c.Request().Context()).ID)
+	if listErr != nil {
+		return listErr
+	}
+
+	component := layout(index(formerNames, watchedCharacters, searchCharacter, err), true)
+	return component.Render(c.Request().Context(), c.Response())
+}
+
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	code := http.StatusInternalServerError
+	message := "Something went wrong. Try again."
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		code = he.Code
+		if msg, ok := he.Message.(string); ok && code != http.StatusInternalServerError {
+			message = msg
+		}
+	}
+	logger := logFromContext(c.Request().Context())
+	if code >= http.StatusInternalServerError {
+		logger.Error("request failed", "err", err)
+	}
+
+	if isAPIRequest(c) {
+		if err := apiErrorJSON(c, code, message); err != nil {
+			logger.Error("failed to write error response", "err", err)
+		}
+		return
+	}
 
-	e.POST("/signup", authService.SignUp)
-	e.POST("/signin", authService.SignIn)
-	e.GET("/signout", authService.SignOut)
+	sess, _ := session.Get("session", c)
+	isLoggedIn := sess != nil && sess.Values["user_id"] != nil
 
-	e.Logger.Fatal(e.Start("0.0.0.0:8080"))
+	c.Response().WriteHeader(code)
+	component := layout(errorPage(code, message), isLoggedIn)
+	if err := component.Render(c.Request().Context(), c.Response()); err != nil {
+		logger.Error("failed to render error page", "err", err)
+	}
 }
 
 func SignUpPage(c echo.Context) error {
@@ -135,7 +351,7 @@ func (a *AuthService) SignUp(c echo.Context) error {
 
 	var errorMsg string
 	if password1 != password2 {
-		errorMsg = "password do not match"
+		errorMsg = errPasswordsDoNotMatch.Error()
 	} else {
 		_, err := a.signUp(email, password1)
 		if err != nil {
@@ -152,8 +368,8 @@ func (a *AuthService) SignUp(c echo.Context) error {
 
 }
 
-func SignInPage(c echo.Context) error {
-	component := layout(signIn(nil), false)
+func (a *AuthService) SignInPage(c echo.Context) error {
+	component := layout(signIn(nil, a.oidcName()), false)
 	return component.Render(c.Request().Context(), c.Response())
 }
 
@@ -161,24 +377,86 @@ func (a *AuthService) SignIn(c echo.Context) error {
 	email := c.FormValue("email")
 	password := c.FormValue("password")
 
-	user, err := a.signIn(email, password)
+	user, err := a.signIn(email, password, c.RealIP())
 	if err != nil {
+		if !errors.Is(err, errInvalidCredentials) && !errors.Is(err, errTooManyAttempts) && !errors.Is(err, errAccountDisabled) {
+			logFromContext(c.Request().Context()).Error("sign in failed", "err", err)
+			err = errInvalidCredentials
+		}
 		var errorMsg = err.Error()
-		component := layout(signIn(&errorMsg), false)
+		component := layout(signIn(&errorMsg, a.oidcName()), false)
 		return component.Render(c.Request().Context(), c.Response())
 	}
 
-	sess, _ := session.Get("session", c)
-	sess.Options = &sessions.Options{
+	return a.startSession(c, user)
+}
+
+func (a *AuthService) oidcName() string {
+	if a.OIDC == nil {
+		return ""
+	}
+
+	return a.OIDC.Name
+}
+
+func sessionOptions() *sessions.Options {
+	return &sessions.Options{
 		Path:     "/",
 		MaxAge:   86400 * 7,
 		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+type userContextKey struct{}
+
+// contextUser returns the user AuthMiddleware signed in for this request.
+func contextUser(ctx context.Context) *sqlc.User {
+	user, _ := ctx.Value(userContextKey{}).(*sqlc.User)
+	return user
+}
+
+func (a *AuthService) currentUser(c echo.Context) (*sqlc.User, error) {
+	if user := contextUser(c.Request().Context()); user != nil {
+		return user, nil
 	}
-	sess.Values["user_id"] = user.ID
 
-	sess.Save(c.Request(), c.Response())
+	sess, _ := session.Get("session", c)
+	userID, ok := sess.Values["user_id"].(int64)
+	if !ok {
+		return nil, echo.ErrUnauthorized
+	}
+
+	user, err := a.Db.GetUserByID(a.Ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Sessions from before the password changed have ended.
+	generation, _ := sess.Values["session_generation"].(int64)
+	if generation != user.SessionGeneration {
+		return nil, echo.ErrUnauthorized
+	}
 
-	return c.Redirect(http.StatusFound, "/")
+	return &user, nil
+}
+
+// AuthRateLimiter limits how often a single IP can post the sign in and sign
+// up forms, on top of the per-account lockout in signIn.
+func AuthRateLimiter() echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Every(6 * time.Second),
+			Burst:     5,
+			ExpiresIn: 15 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			logFromContext(c.Request().Context()).Warn("rate limited", "ip", identifier, "path", c.Request().URL.Path)
+			return echo.NewHTTPError(http.StatusTooManyRequests, "Too many attempts. Wait a minute and try again.")
+		},
+	})
 }
 
 func (a *AuthService) SignOut(c echo.Context) error {
@@ -189,17 +467,48 @@ func (a *AuthService) SignOut(c echo.Context) error {
 	return c.Redirect(http.StatusFound, "/signin")
 }
 
+// publicPaths can be requested without signing in.
+var publicPaths = map[string]bool{
+	"/signin":               true,
+	"/signup":               true,
+	"/signin/2fa":           true,
+	"/account/email/verify": true,
+	"/api/openapi.json":     true,
+	"/healthz":              true,
+	"/readyz":               true,
+}
+
 func (a *AuthService) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		if c.Request().URL.Path == "/signin" || c.Request().URL.Path == "/signup" {
+		path := c.Request().URL.Path
+		if publicPaths[path] || strings.HasPrefix(path, "/signin/oidc") {
 			return next(c)
 		}
 
+		if hasBearerToken(c) {
+			return a.apiTokenAuth(c, next)
+		}
+
 		sess, _ := session.Get("session", c)
 		if sess.Values["user_id"] == nil {
-			fmt.Println("no id. redirecting")
+			if isAPIRequest(c) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+			}
+			return c.Redirect(http.StatusFound, "/signin")
+		}
+
+		user, err := a.currentUser(c)
+		if err != nil || user.Disabled {
+			logFromContext(c.Request().Context()).Info("signing out unknown or disabled user", "err", err)
+			sess.Options.MaxAge = -1
+			sess.Save(c.Request(), c.Response())
+			if isAPIRequest(c) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+			}
 			return c.Redirect(http.StatusFound, "/signin")
 		}
+		ctx := context.WithValue(c.Request().Context(), userContextKey{}, user)
+		c.SetRequest(c.Request().WithContext(ctx))
 
 		return next(c)
 	}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tibiaDataRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "tibiabuddy_tibiadata_request_duration_seconds",
+		Help: "Latency of TibiaData requests by mirror, HTTP status (or \"error\" without a response) and TibiaData error code.",
+	}, []string{"mirror", "code", "api_error"})
+
+	tibiaDataBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tibiabuddy_tibiadata_breaker_state",
+		Help: "Circuit breaker state per TibiaData mirror, 0 closed, 1 open, 2 half-open.",
+	}, []string{"mirror"})
+
+	cacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tibiabuddy_cache_lookups_total",
+		Help: "Cached lookups by result, hit, revalidated, miss or bypass.",
+	}, []string{"result"})
+
+	checksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tibiabuddy_checks_total",
+		Help: "Name checks by resulting status, \"skipped\" when TibiaData did not answer and \"error\" when the lookup failed otherwise.",
+	}, []string{"status"})
+
+	statusTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tibiabuddy_status_transitions_total",
+		Help: "Status changes of tracked names.",
+	}, []string{"from", "to"})
+
+	characterRenamesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tibiabuddy_character_renames_total",
+		Help: "Renames of watched characters noticed by the poller.",
+	})
+
+	notificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tibiabuddy_notifications_total",
+		Help: "Notifications by channel and result (sent or failed).",
+	}, []string{"channel", "result"})
+
+	pollPassDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tibiabuddy_poll_pass_duration_seconds",
+		Help:    "Duration of a poller pass over all tracked names.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	pollLastPassFinished = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tibiabuddy_poll_last_pass_finished_timestamp_seconds",
+		Help: "Unix time the last poller pass finished, for alerting when the poller stalls.",
+	})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "tibiabuddy_http_request_duration_seconds",
+		Help: "Latency of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	trackedNamesDesc = prometheus.NewDesc(
+		"tibiabuddy_tracked_names",
+		"Tracked names by current status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// MetricsMiddleware records the latency of every request by route pattern
+// rather than path, so names in URLs do not blow up the label count. It has
+// to run before RequestLogger, which turns errors into responses.
+func MetricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request().Method, route, strconv.Itoa(c.Response().Status)).
+			Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// trackedNamesCollector counts the tracked names per status from the
+// database whenever metrics are scraped.
+type trackedNamesCollector struct {
+	Db *repositoryClient
+}
+
+func (t trackedNamesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- trackedNamesDesc
+}
+
+func (t trackedNamesCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := t.Db.CountFormerNamesByStatus(context.Background())
+	if err != nil {
+		slog.Error("failed to count tracked names", "err", err)
+		ch <- prometheus.NewInvalidMetric(trackedNamesDesc, err)
+		return
+	}
+
+	for _, status := range []FormerNameStatus{available, expiring, unavailable, unknown} {
+		ch <- prometheus.MustNewConstMetric(trackedNamesDesc, prometheus.GaugeValue, float64(counts[status]), status.String())
+	}
+}
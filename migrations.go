@@ -0,0 +1,183 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationSteps run after the script with the same number, in its
+// transaction, for changes that have to work on names the way the
+// application does.
+var migrationSteps = map[int]func(tx *sql.Tx) error{
+	7: migrateFormerNameKeys,
+}
+
+// migrate applies the numbered scripts in migrations/ that are newer than the
+// database's user_version, each in its own transaction. The same directory is
+// the schema sqlc generates the queries from.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		number, _, _ := strings.Cut(path.Base(file), "_")
+		fileVersion, err := strconv.Atoi(number)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", file, err)
+		}
+		if fileVersion <= version {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %w", file, err)
+		}
+		if step, ok := migrationSteps[fileVersion]; ok {
+			if err := step(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %s: %w", file, err)
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", fileVersion)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		version = fileVersion
+	}
+
+	return nil
+}
+
+// migrateFormerNameKeys normalizes tracked names and sets their name_key with
+// normalizeName and nameKey, which know about more than the ASCII letters
+// lower() in SQLite does. Of names a user tracks more than once, the one
+// checked last is kept with the notification emails of all of them, and the
+// history is moved to the name it is looked up by. notify_when does not
+// exist yet at this version, so the emails are all there is to merge.
+func migrateFormerNameKeys(tx *sql.Tx) error {
+	type userKey struct {
+		userID sql.NullInt64
+		key    string
+	}
+	type keptName struct {
+		id     int64
+		name   string
+		emails []string
+	}
+
+	names, err := queryMigrationNames(tx, `SELECT id, user_id, name, notification_emails FROM former_names WHERE name IS NOT NULL ORDER BY last_checked DESC, id DESC`)
+	if err != nil {
+		return err
+	}
+	kept := map[userKey]*keptName{}
+	var order []userKey
+	for _, n := range names {
+		k := userKey{n.userID, nameKey(n.name)}
+		if kn, ok := kept[k]; ok {
+			kn.emails = mergeEmails(kn.emails, n.emails.String)
+			if _, err := tx.Exec(`DELETE FROM former_names WHERE id = ?`, n.id); err != nil {
+				return err
+			}
+			continue
+		}
+		kept[k] = &keptName{id: n.id, name: normalizeName(n.name), emails: mergeEmails(nil, n.emails.String)}
+		order = append(order, k)
+	}
+	for _, k := range order {
+		kn := kept[k]
+		_, err := tx.Exec(`UPDATE former_names SET name = ?, name_key = ?, notification_emails = ? WHERE id = ?`, kn.name, k.key, strings.Join(kn.emails, ","), kn.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes, err := queryMigrationNames(tx, `SELECT id, user_id, name, NULL FROM status_changes`)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		kn, ok := kept[userKey{c.userID, nameKey(c.name)}]
+		if !ok || kn.name == c.name {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE status_changes SET name = ? WHERE id = ?`, kn.name, c.id); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		DROP INDEX IF EXISTS former_names_user_name;
+		CREATE UNIQUE INDEX IF NOT EXISTS former_names_user_name_key ON former_names (user_id, name_key);
+	`)
+
+	return err
+}
+
+// mergeEmails adds the comma separated addresses in list to emails, leaving
+// out those already there in any case.
+func mergeEmails(emails []string, list string) []string {
+	for _, email := range strings.Split(list, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" || slices.ContainsFunc(emails, func(e string) bool { return strings.EqualFold(e, email) }) {
+			continue
+		}
+		emails = append(emails, email)
+	}
+
+	return emails
+}
+
+type migrationName struct {
+	id     int64
+	userID sql.NullInt64
+	name   string
+	emails sql.NullString
+}
+
+// queryMigrationNames reads all rows of query, which selects an id, a user
+// id, a name and notification emails, before the caller changes them.
+func queryMigrationNames(tx *sql.Tx, query string) ([]migrationName, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []migrationName
+	for rows.Next() {
+		var n migrationName
+		if err := rows.Scan(&n.id, &n.userID, &n.name, &n.emails); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS former_names (
+	id INTEGER PRIMARY KEY,
+	name TEXT,
+	notification_emails TEXT,
+	last_checked DATETIME,
+	last_updated_status DATETIME,
+	status TEXT
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	email TEXT NOT NULL UNIQUE,
+	hashed_password BLOB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+	id VARCHAR(36) PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	created DATETIME CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	email TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	success BOOLEAN NOT NULL,
+	created DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_email ON login_attempts (email, created);
+CREATE INDEX IF NOT EXISTS login_attempts_ip ON login_attempts (ip, created);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	secret TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT 0,
+	created DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	hashed_code BLOB NOT NULL,
+	used DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+	issuer TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	email TEXT NOT NULL,
+	created DATETIME NOT NULL,
+	PRIMARY KEY (issuer, subject)
+);
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	former_name TEXT NOT NULL,
+	emails TEXT NOT NULL,
+	error TEXT,
+	created DATETIME NOT NULL
+);
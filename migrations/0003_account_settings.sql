@@ -0,0 +1,14 @@
+ALTER TABLE former_names ADD COLUMN user_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+
+-- Names used to be shared by everyone. They are left without an owner: the
+-- poller keeps checking them and emailing their addresses, but no account
+-- sees them until "tibiabuddy names adopt <email>" gives them to one.
+
+CREATE INDEX IF NOT EXISTS former_names_user_name ON former_names (user_id, name);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+	hashed_token BLOB PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	email TEXT NOT NULL,
+	expires DATETIME NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS status_changes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	old_status TEXT NOT NULL,
+	new_status TEXT NOT NULL,
+	created DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS status_changes_user_name ON status_changes (user_id, name, created);
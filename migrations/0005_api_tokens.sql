@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	hashed_token BLOB NOT NULL UNIQUE,
+	scope TEXT NOT NULL,
+	created DATETIME NOT NULL,
+	last_used DATETIME,
+	revoked DATETIME
+);
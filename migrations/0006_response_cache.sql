@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS response_cache (
+	url TEXT PRIMARY KEY,
+	status_code INTEGER NOT NULL,
+	body BLOB NOT NULL,
+	etag TEXT NOT NULL,
+	last_modified TEXT NOT NULL,
+	fetched DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS response_cache_fetched ON response_cache (fetched);
@@ -0,0 +1,4 @@
+ALTER TABLE former_names ADD COLUMN name_key TEXT;
+
+-- Names are normalized, their keys set, duplicates removed and the unique
+-- index on (user_id, name_key) created by migrateFormerNameKeys in Go.
@@ -0,0 +1,2 @@
+-- 0 notifies when the name becomes available, 1 when someone takes it.
+ALTER TABLE former_names ADD COLUMN notify_when INTEGER NOT NULL DEFAULT 0;
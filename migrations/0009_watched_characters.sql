@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS watched_characters (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	-- The current name of the character, updated when it is renamed.
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	world TEXT NOT NULL,
+	notification_emails TEXT NOT NULL,
+	last_checked DATETIME NOT NULL,
+	last_renamed DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS watched_characters_user_name_key ON watched_characters (user_id, name_key);
+
+CREATE TABLE IF NOT EXISTS character_renames (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	watched_character_id INTEGER NOT NULL REFERENCES watched_characters (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	old_name TEXT NOT NULL,
+	new_name TEXT NOT NULL,
+	created DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS character_renames_watched_character ON character_renames (watched_character_id, created);
@@ -0,0 +1,6 @@
+-- Sessions are kept in the cookie. Raising the generation of a user ends
+-- every session started with an older one, see currentUser.
+ALTER TABLE users ADD COLUMN session_generation INTEGER NOT NULL DEFAULT 0;
+
+-- Never used, sessions have always been kept in the cookie.
+DROP TABLE IF EXISTS user_sessions;
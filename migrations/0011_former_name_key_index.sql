@@ -0,0 +1,2 @@
+-- Admins re-check a name for everyone tracking it, whatever the owner.
+CREATE INDEX IF NOT EXISTS former_names_name_key ON former_names (name_key);
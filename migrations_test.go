@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+// openDatabaseAt returns a database migrated up to version, for seeding the
+// rows a later migration has to handle.
+func openDatabaseAt(t *testing.T, version int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files[:version] {
+		script, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(script)); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestMigrateFormerNameKeysMergesDuplicates(t *testing.T) {
+	db := openDatabaseAt(t, 6)
+	_, err := db.Exec(`
+		INSERT INTO users (id, email, hashed_password) VALUES (1, 'a@example.com', ''), (2, 'b@example.com', '');
+		INSERT INTO former_names (id, user_id, name, notification_emails, last_checked, status) VALUES
+			(1, 1, 'bubble', 'old@example.com, Shared@example.com', '2024-01-01 00:00:00', 0),
+			(2, 1, 'Bubble', 'new@example.com,shared@example.com', '2024-02-01 00:00:00', 0),
+			(3, 1, 'ölaf', 'olaf@example.com', '2024-03-01 00:00:00', 0),
+			(4, 1, 'Ölaf', NULL, '2024-01-01 00:00:00', 0),
+			(5, 2, 'BUBBLE', 'b@example.com', '2024-01-01 00:00:00', 0),
+			(6, NULL, 'bubble', 'legacy@example.com', '2024-01-01 00:00:00', 0);
+		INSERT INTO status_changes (user_id, name, old_status, new_status, created) VALUES
+			(1, 'bubble', '0', '1', '2024-01-01 00:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query(`SELECT id, user_id, name, name_key, notification_emails FROM former_names ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var id int64
+		var userID sql.NullInt64
+		var name, key, emails string
+		if err := rows.Scan(&id, &userID, &name, &key, &emails); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d %v %s %s %s", id, userID.Int64, name, key, emails))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2 1 Bubble bubble new@example.com,shared@example.com,old@example.com",
+		"3 1 Ölaf ölaf olaf@example.com",
+		"5 2 BUBBLE bubble b@example.com",
+		"6 0 Bubble bubble legacy@example.com",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("former names =\n%q\nwant\n%q", got, want)
+	}
+
+	var name string
+	if err := db.QueryRow(`SELECT name FROM status_changes`).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Bubble" {
+		t.Errorf("status change name = %q, want %q", name, "Bubble")
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"rustydoggobytes/tibiabuddy/tibiacom"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// mirrorFailureThreshold is the number of failed requests in a row after
+// which a mirror's breaker opens.
+const mirrorFailureThreshold = 3
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// characterSource looks characters up, *tibiadata.Client or *tibiacom.Client.
+type characterSource interface {
+	Character(ctx context.Context, name string) (*tibiadata.CharacterInfo, error)
+}
+
+// tibiaDataMirror is one TibiaData instance, or the website, behind a
+// circuit breaker. After mirrorFailureThreshold failures in a row the
+// breaker opens and the mirror is skipped until Cooldown has passed, then a
+// single probe request decides whether it closes again.
+type tibiaDataMirror struct {
+	URL      string
+	Source   characterSource
+	Cooldown time.Duration
+
+	mu          sync.Mutex
+	state       breakerState
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+// MirrorStatus is what the admin console shows about a mirror.
+type MirrorStatus struct {
+	URL         string
+	State       string
+	Failures    int
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+// newTibiaDataMirror talks to the TibiaData instance at rawURL. A file://
+// URL points at a directory of recorded responses instead, see
+// tibiadata.FixtureTransport.
+func newTibiaDataMirror(rawURL string, cooldown time.Duration, cache *responseCache) (*tibiaDataMirror, error) {
+	httpClient, fixtures, err := mirrorHTTPClient(rawURL, cache, func(fsys fs.FS) http.RoundTripper {
+		return tibiadata.FixtureTransport{FS: fsys}
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := tibiadata.NewClient(rawURL)
+	if fixtures {
+		client.BaseURL = "http://fixtures"
+	}
+	client.HTTPClient = httpClient
+
+	return newMirror(rawURL, client, cooldown), nil
+}
+
+// newTibiaComMirror reads character pages of the website at rawURL, or
+// saved pages for a file:// URL, see tibiacom.FixtureTransport.
+func newTibiaComMirror(rawURL string, cooldown time.Duration, cache *responseCache) (*tibiaDataMirror, error) {
+	httpClient, fixtures, err := mirrorHTTPClient(rawURL, cache, func(fsys fs.FS) http.RoundTripper {
+		return tibiacom.FixtureTransport{FS: fsys}
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := tibiacom.NewClient(rawURL)
+	if fixtures {
+		client.BaseURL = "http://fixtures"
+	}
+	client.HTTPClient = httpClient
+
+	return newMirror(rawURL, client, cooldown), nil
+}
+
+func newMirror(rawURL string, source characterSource, cooldown time.Duration) *tibiaDataMirror {
+	tibiaDataBreakerState.WithLabelValues(rawURL).Set(float64(breakerClosed))
+
+	return &tibiaDataMirror{URL: rawURL, Source: source, Cooldown: cooldown}
+}
+
+// mirrorHTTPClient returns the client for requests to rawURL, which reads
+// from the directory of a file:// URL through the transport fixtures makes.
+// Requests go through cache unless it is nil.
+func mirrorHTTPClient(rawURL string, cache *responseCache, fixtures func(fs.FS) http.RoundTripper) (_ *http.Client, isFixtures bool, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	transport := http.DefaultTransport
+	if u.Scheme == "file" {
+		transport = fixtures(os.DirFS(u.Path))
+		isFixtures = true
+	}
+	// Passes the trace context on. Cache hits are not traced as requests.
+	transport = otelhttp.NewTransport(transport)
+	if cache != nil {
+		transport = cache.Transport(transport)
+	}
+
+	return &http.Client{Transport: transport}, isFixtures, nil
+}
+
+// allow reports whether a request may be sent to the mirror and, when it
+// may, whether the mirror is degraded: it failed since its last success or
+// the request is the probe of a half-open breaker.
+func (m *tibiaDataMirror) allow() (allowed, degraded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch m.state {
+	case breakerOpen:
+		if time.Since(m.openedAt) < m.Cooldown {
+			return false, true
+		}
+		m.setState(breakerHalfOpen)
+		m.probing = true
+		return true, true
+	case breakerHalfOpen:
+		if m.probing {
+			return false, true
+		}
+		m.probing = true
+		return true, true
+	default:
+		return true, m.failures > 0
+	}
+}
+
+// record updates the breaker with the outcome of a request that allow let
+// through. A cancelled request leaves it as it is, like skip.
+func (m *tibiaDataMirror) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if !mirrorFailed(err) {
+		m.failures = 0
+		m.lastSuccess = time.Now()
+		m.setState(breakerClosed)
+		return
+	}
+
+	m.failures++
+	m.lastFailure = time.Now()
+	m.lastError = err.Error()
+	if m.state == breakerHalfOpen || m.failures >= mirrorFailureThreshold {
+		m.openedAt = time.Now()
+		m.setState(breakerOpen)
+	}
+}
+
+// skip gives the probe of a half-open breaker back after a request that
+// allow let through was answered from the cache. The mirror was not asked,
+// so the breaker stays as it is.
+func (m *tibiaDataMirror) skip() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.probing = false
+}
+
+func (m *tibiaDataMirror) setState(state breakerState) {
+	m.state = state
+	tibiaDataBreakerState.WithLabelValues(m.URL).Set(float64(state))
+}
+
+func (m *tibiaDataMirror) Status() MirrorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return MirrorStatus{
+		URL:         m.URL,
+		State:       m.state.String(),
+		Failures:    m.failures,
+		LastSuccess: m.lastSuccess,
+		LastFailure: m.lastFailure,
+		LastError:   m.lastError,
+	}
+}
+
+// mirrorFailed reports whether err means the mirror is not working, as
+// opposed to an answer like "no such character". Cancelled requests say
+// nothing about the mirror.
+func mirrorFailed(err error) bool {
+	return isUpstreamError(err) && !errors.Is(err, context.Canceled)
+}
+
+// isUpstreamError reports whether err means that no answer about the name
+// could be had, because a mirror failed, limited the rate or is down for
+// maintenance. Lookups failing like this must not change a status.
+func isUpstreamError(err error) bool {
+	return errors.Is(err, tibiadata.ErrUpstream) ||
+		errors.Is(err, tibiadata.ErrRateLimited) ||
+		errors.Is(err, tibiadata.ErrMaintenance) ||
+		errors.Is(err, errNoMirror)
+}
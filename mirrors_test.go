@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"testing"
+	"time"
+)
+
+func TestMirrorBreaker(t *testing.T) {
+	// A step is a request allow lets through or not, its outcome handed to
+	// record, a cached answer handed to skip, or the cooldown passing.
+	type step struct {
+		action            string
+		err               error
+		allowed, degraded bool
+	}
+	allow := func(allowed, degraded bool) step { return step{action: "allow", allowed: allowed, degraded: degraded} }
+	record := func(err error) step { return step{action: "record", err: err} }
+	skip := step{action: "skip"}
+	cooldown := step{action: "cooldown"}
+	fail := record(tibiadata.ErrUpstream)
+	succeed := record(nil)
+
+	tests := []struct {
+		name     string
+		steps    []step
+		state    string
+		failures int
+	}{
+		{"healthy", []step{allow(true, false), succeed, allow(true, false)}, "closed", 0},
+		{"degraded below the threshold", []step{fail, fail, allow(true, true)}, "closed", 2},
+		{"opens at the threshold", []step{fail, fail, fail, allow(false, true)}, "open", 3},
+		{"success resets the failures", []step{fail, fail, succeed, fail, fail, allow(true, true)}, "closed", 2},
+		{"not found is an answer", []step{fail, fail, record(tibiadata.ErrNotFound), fail, fail}, "closed", 2},
+		{"invalid name is an answer", []step{fail, fail, record(tibiadata.ErrInvalidName), fail}, "closed", 1},
+		{"rate limits count", []step{record(tibiadata.ErrRateLimited), record(tibiadata.ErrMaintenance), fail}, "open", 3},
+		{"cancelled requests do not count", []step{fail, fail, record(fmt.Errorf("%w: %w", tibiadata.ErrUpstream, context.Canceled)), allow(true, true)}, "closed", 2},
+		{"half-open after the cooldown", []step{fail, fail, fail, cooldown, allow(true, true)}, "half-open", 3},
+		{"one probe at a time", []step{fail, fail, fail, cooldown, allow(true, true), allow(false, true)}, "half-open", 3},
+		{"probe closes", []step{fail, fail, fail, cooldown, allow(true, true), succeed, allow(true, false)}, "closed", 0},
+		{"failed probe opens again", []step{fail, fail, fail, cooldown, allow(true, true), fail, allow(false, true)}, "open", 4},
+		{"cached answer gives the probe back", []step{fail, fail, fail, cooldown, allow(true, true), skip, allow(true, true)}, "half-open", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMirror("http://mirror.test", nil, time.Minute)
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					allowed, degraded := m.allow()
+					if allowed != s.allowed || degraded != s.degraded {
+						t.Fatalf("step %d: allowed, degraded = %v, %v, want %v, %v", i, allowed, degraded, s.allowed, s.degraded)
+					}
+				case "record":
+					m.record(s.err)
+				case "skip":
+					m.skip()
+				case "cooldown":
+					m.openedAt = m.openedAt.Add(-m.Cooldown)
+				}
+			}
+
+			status := m.Status()
+			if status.State != tt.state || status.Failures != tt.failures {
+				t.Errorf("state, failures = %s, %d, want %s, %d", status.State, status.Failures, tt.state, tt.failures)
+			}
+		})
+	}
+}
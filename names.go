@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits character creation puts on names.
+const (
+	minNameLength     = 2
+	maxNameLength     = 29
+	maxNameWords      = 3
+	minNameWordLength = 2
+	maxNameWordLength = 14
+)
+
+// bannedNameParts may not appear anywhere in a name, bannedNamePrefixes not
+// at the start of a word and bannedNameWords not as a word of it, as they
+// pass for staff or the game itself. Prefixes are common inside ordinary
+// words, like "admin" in "Badminton".
+var (
+	bannedNameParts    = []string{"cipsoft", "gamemaster", "tibia"}
+	bannedNamePrefixes = []string{"admin"}
+	bannedNameWords    = map[string]bool{"cm": true, "gm": true, "god": true, "support": true, "tutor": true}
+)
+
+// normalizeName writes name the way Tibia shows character names: runs of
+// whitespace collapsed into single spaces, none at either end and every
+// word starting with a capital letter. The rest of a word is left alone, as
+// some old names have capitals in the middle.
+func normalizeName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// nameKey is what names are stored and compared by. Tibia does not tell
+// names apart by case, so "bubble  tea" and "Bubble Tea" have the same key.
+func nameKey(name string) string {
+	return strings.ToLower(normalizeName(name))
+}
+
+func sameName(a, b string) bool {
+	return nameKey(a) == nameKey(b)
+}
+
+// validateName checks name against the rules for new characters and says
+// which one it breaks, wrapped in errInvalidName. Some old characters have
+// names that break these rules, but nobody can claim such a name anymore.
+func validateName(name string) error {
+	name = normalizeName(name)
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", errInvalidName, fmt.Sprintf(format, args...))
+	}
+
+	if length := utf8.RuneCountInString(name); length < minNameLength || length > maxNameLength {
+		return invalid("must be %d to %d characters long", minNameLength, maxNameLength)
+	}
+	for _, r := range name {
+		if r != ' ' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return invalid("may only contain the letters A to Z and spaces, not %q", r)
+		}
+	}
+
+	words := strings.Fields(name)
+	if len(words) > maxNameWords {
+		return invalid("may have at most %d words", maxNameWords)
+	}
+	key := nameKey(name)
+	for _, part := range bannedNameParts {
+		if strings.Contains(key, part) {
+			return invalid("may not contain %q", part)
+		}
+	}
+	for _, word := range strings.Fields(key) {
+		if len(word) < minNameWordLength || len(word) > maxNameWordLength {
+			return invalid("words must be %d to %d letters long", minNameWordLength, maxNameWordLength)
+		}
+		if bannedNameWords[word] {
+			return invalid("may not contain the word %q", word)
+		}
+		for _, prefix := range bannedNamePrefixes {
+			if strings.HasPrefix(word, prefix) {
+				return invalid("words may not start with %q", prefix)
+			}
+		}
+		for i := 2; i < len(word); i++ {
+			if word[i] == word[i-1] && word[i] == word[i-2] {
+				return invalid("may not have the same letter three times in a row")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name string
+		// rule is part of the message of the rule the name breaks, empty
+		// for valid names.
+		rule string
+	}{
+		{"Bubble", ""},
+		{"bubble  tea", ""},
+		{"Ab", ""},
+		{"Abcdefghijklmn Abcdefghijklmn", ""},
+		{"Badminton", ""},
+		{"Sir Badminton", ""},
+		{"Goddess", ""},
+
+		{"A", "must be 2 to 29 characters long"},
+		{"   A   ", "must be 2 to 29 characters long"},
+		{"Abcdefghij Abcdefghij Abcdefghij", "must be 2 to 29 characters long"},
+
+		{"Bubble2", "may only contain the letters A to Z"},
+		{"Bübble", "may only contain the letters A to Z"},
+		{"Bubble-Tea", "may only contain the letters A to Z"},
+		{"Bubble's", "may only contain the letters A to Z"},
+
+		{"Ab Cd Ef Gh", "may have at most 3 words"},
+
+		{"Mytibiachar", `may not contain "tibia"`},
+		{"Cipsoft Fan", `may not contain "cipsoft"`},
+		{"Thegamemaster", `may not contain "gamemaster"`},
+
+		{"Admin", `may not start with "admin"`},
+		{"Sir Administrator", `may not start with "admin"`},
+
+		{"Gm Bubble", `may not contain the word "gm"`},
+		{"Cm Bubble", `may not contain the word "cm"`},
+		{"God Bubble", `may not contain the word "god"`},
+		{"Bubble Tutor", `may not contain the word "tutor"`},
+		{"Support Me", `may not contain the word "support"`},
+
+		{"A Bubble", "words must be 2 to 14 letters long"},
+		{"Abcdefghijklmno", "words must be 2 to 14 letters long"},
+
+		{"Buuubble", "same letter three times in a row"},
+		{"Sir Aaaron", "same letter three times in a row"},
+		{"Baaa", "same letter three times in a row"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateName(tt.name)
+			if tt.rule == "" {
+				if err != nil {
+					t.Errorf("err = %v, want a valid name", err)
+				}
+				return
+			}
+			if !errors.Is(err, errInvalidName) {
+				t.Fatalf("err = %v, want %v", err, errInvalidName)
+			}
+			if !strings.Contains(err.Error(), tt.rule) {
+				t.Errorf("err = %v, want it to break the rule %q", err, tt.rule)
+			}
+		})
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name, normalized, key string
+	}{
+		{"bubble", "Bubble", "bubble"},
+		{"  bubble   tea ", "Bubble Tea", "bubble tea"},
+		{"BUBBLE TEA", "BUBBLE TEA", "bubble tea"},
+		{"McBubble", "McBubble", "mcbubble"},
+		{"ölaf", "Ölaf", "ölaf"},
+	}
+	for _, tt := range tests {
+		if got := normalizeName(tt.name); got != tt.normalized {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.name, got, tt.normalized)
+		}
+		if got := nameKey(tt.name); got != tt.key {
+			t.Errorf("nameKey(%q) = %q, want %q", tt.name, got, tt.key)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/oauth2"
+)
+
+var (
+	errOIDCSignIn = errors.New("sign in with your identity provider failed, try again")
+	errOIDCReauth = errors.New("this identity is not linked to your account")
+)
+
+type OIDCProvider struct {
+	Name     string
+	issuer   string
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+}
+
+// NewOIDCProvider discovers the provider's endpoints from
+// issuerURL/.well-known/openid-configuration.
+func NewOIDCProvider(ctx context.Context, name, issuerURL, clientID, clientSecret, redirectURL string) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCProvider{
+		Name:   name,
+		issuer: issuerURL,
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+// oidcSignIn returns the user linked to the identity, linking it by email
+// on first use and creating the account if there is none.
+func (a AuthService) oidcSignIn(issuer, subject, email string) (*sqlc.User, error) {
+	user, err := a.Db.GetUserByIdentity(a.Ctx, sqlc.GetUserByIdentityParams{Issuer: issuer, Subject: subject})
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	user, err = a.Db.GetUser(a.Ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The account can only be used through the identity provider until
+		// a password is set, as nobody knows this one.
+		password, err := randomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		newUser, err := a.signUp(email, password)
+		if err != nil {
+			return nil, err
+		}
+		user = *newUser
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = a.Db.CreateUserIdentity(a.Ctx, sqlc.CreateUserIdentityParams{
+		Issuer:  issuer,
+		Subject: subject,
+		UserID:  user.ID,
+		Email:   email,
+		Created: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// OIDCSignIn redirects to the provider using the authorization code flow
+// with PKCE. State, nonce and verifier are kept in the session until the
+// provider redirects back to OIDCCallback. With ?reauth=1 a signed in user
+// confirms changes to the account instead of signing in, see confirmUser.
+func (a *AuthService) OIDCSignIn(c echo.Context) error {
+	if a.OIDC == nil {
+		return echo.ErrNotFound
+	}
+
+	state, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	sess, _ := session.Get("session", c)
+	sess.Options = sessionOptions()
+	sess.Values["oidc_state"] = state
+	sess.Values["oidc_nonce"] = nonce
+	sess.Values["oidc_verifier"] = verifier
+	if _, ok := sess.Values["user_id"].(int64); ok && c.QueryParam("reauth") != "" {
+		sess.Values["oidc_reauth"] = true
+	}
+	sess.Save(c.Request(), c.Response())
+
+	url := a.OIDC.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return c.Redirect(http.StatusFound, url)
+}
+
+func (a *AuthService) OIDCCallback(c echo.Context) error {
+	if a.OIDC == nil {
+		return echo.ErrNotFound
+	}
+
+	sess, _ := session.Get("session", c)
+	state, _ := sess.Values["oidc_state"].(string)
+	nonce, _ := sess.Values["oidc_nonce"].(string)
+	verifier, _ := sess.Values["oidc_verifier"].(string)
+	reauth, _ := sess.Values["oidc_reauth"].(bool)
+	delete(sess.Values, "oidc_state")
+	delete(sess.Values, "oidc_nonce")
+	delete(sess.Values, "oidc_verifier")
+	delete(sess.Values, "oidc_reauth")
+	sess.Save(c.Request(), c.Response())
+
+	if state == "" || c.QueryParam("state") != state {
+		return a.oidcSignInFailed(c, errors.New("state mismatch"))
+	}
+	if errParam := c.QueryParam("error"); errParam != "" {
+		return a.oidcSignInFailed(c, errors.New(errParam+": "+c.QueryParam("error_description")))
+	}
+
+	ctx := c.Request().Context()
+	token, err := a.OIDC.config.Exchange(ctx, c.QueryParam("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		return a.oidcSignInFailed(c, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return a.oidcSignInFailed(c, errors.New("no id_token in token response"))
+	}
+	idToken, err := a.OIDC.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return a.oidcSignInFailed(c, err)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return a.oidcSignInFailed(c, err)
+	}
+	if claims.Nonce != nonce {
+		return a.oidcSignInFailed(c, errors.New("nonce mismatch"))
+	}
+	if reauth {
+		return a.oidcReauthenticate(c, idToken.Subject)
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		errorMsg := "Your identity provider did not return a verified email address."
+		component := layout(signIn(&errorMsg, a.OIDC.Name), false)
+		return component.Render(c.Request().Context(), c.Response())
+	}
+
+	user, err := a.oidcSignIn(a.OIDC.issuer, idToken.Subject, claims.Email)
+	if err != nil {
+		return a.oidcSignInFailed(c, err)
+	}
+	if err := a.recordFirstFactor(user, c.RealIP()); err != nil {
+		return err
+	}
+
+	return a.startSession(c, user)
+}
+
+// oidcReauthenticate lets the signed in user make changes to the account
+// without the current password for reauthTimeout, when subject is the
+// identity linked to it. Nothing is linked or created here.
+func (a *AuthService) oidcReauthenticate(c echo.Context, subject string) error {
+	sess, _ := session.Get("session", c)
+	userID, ok := sess.Values["user_id"].(int64)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/signin")
+	}
+	user, err := a.Db.GetUserByIdentity(a.Ctx, sqlc.GetUserByIdentityParams{Issuer: a.OIDC.issuer, Subject: subject})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.ID != userID) {
+		logFromContext(c.Request().Context()).Warn("oidc reauthentication with an identity of another account", "user_id", userID, "ip", c.RealIP())
+		return a.renderAccountPage(c, "", errOIDCReauth)
+	}
+	if err != nil {
+		return err
+	}
+
+	sess.Values["reauth_user_id"] = userID
+	sess.Values["reauth_since"] = time.Now().Unix()
+	sess.Save(c.Request(), c.Response())
+
+	return c.Redirect(http.StatusFound, "/account")
+}
+
+func (a *AuthService) oidcSignInFailed(c echo.Context, err error) error {
+	logFromContext(c.Request().Context()).Warn("oidc sign in failed", "ip", c.RealIP(), "err", err)
+
+	errorMsg := errOIDCSignIn.Error()
+	component := layout(signIn(&errorMsg, a.OIDC.Name), false)
+	return component.Render(c.Request().Context(), c.Response())
+}
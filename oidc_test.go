@@ -0,0 +1,495 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	mockOIDCClientID     = "tibiabuddy"
+	mockOIDCClientSecret = "secret"
+)
+
+// mockOIDCProvider is an identity provider with discovery, keys and a token
+// endpoint. Authorization is done by the test with authorize, which hands
+// out the code the provider redirects back with.
+type mockOIDCProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu            sync.Mutex
+	grants        map[string]mockOIDCGrant
+	tokenRequests int
+}
+
+// mockOIDCGrant is what the user agreed to at the provider. Challenge and
+// nonce are taken from the authorization request unless set.
+type mockOIDCGrant struct {
+	subject       string
+	email         string
+	emailVerified bool
+	nonce         string
+	challenge     string
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockOIDCProvider{key: key, grants: map[string]mockOIDCGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+// authorize checks the authorization request the app redirected to and
+// returns the code for grant.
+func (p *mockOIDCProvider) authorize(t *testing.T, authURL string, grant mockOIDCGrant) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if !strings.HasPrefix(authURL, p.URL+"/authorize?") {
+		t.Fatalf("redirected to %s, want the authorization endpoint", authURL)
+	}
+	if query.Get("response_type") != "code" || query.Get("client_id") != mockOIDCClientID {
+		t.Errorf("response_type, client_id = %q, %q, want code, %s", query.Get("response_type"), query.Get("client_id"), mockOIDCClientID)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Errorf("code_challenge_method, code_challenge = %q, %q, want S256 and a challenge", query.Get("code_challenge_method"), query.Get("code_challenge"))
+	}
+	if query.Get("state") == "" || query.Get("nonce") == "" {
+		t.Errorf("state, nonce = %q, %q, want both", query.Get("state"), query.Get("nonce"))
+	}
+
+	if grant.nonce == "" {
+		grant.nonce = query.Get("nonce")
+	}
+	if grant.challenge == "" {
+		grant.challenge = query.Get("code_challenge")
+	}
+	code, err := randomToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.grants[code] = grant
+	p.mu.Unlock()
+
+	return code
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.tokenRequests++
+	grant, ok := p.grants[r.FormValue("code")]
+	delete(p.grants, r.FormValue("code"))
+	p.mu.Unlock()
+
+	clientID, clientSecret, basic := r.BasicAuth()
+	if !basic {
+		clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	switch {
+	case clientID != mockOIDCClientID || clientSecret != mockOIDCClientSecret:
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	case !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: p.key, KeyID: "test"}}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	claims, _ := json.Marshal(map[string]any{
+		"iss":            p.URL,
+		"sub":            grant.subject,
+		"aud":            mockOIDCClientID,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.email,
+		"email_verified": grant.emailVerified,
+	})
+	signed, err := signer.Sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idToken, _ := signed.CompactSerialize()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+type oidcTestApp struct {
+	*httptest.Server
+	auth     *AuthService
+	provider *mockOIDCProvider
+}
+
+func newOIDCTestApp(t *testing.T) *oidcTestApp {
+	t.Helper()
+	db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	app := &oidcTestApp{auth: NewAuthService(db.Db, nil, ""), provider: newMockOIDCProvider(t)}
+	e := echo.New()
+	e.Use(session.Middleware(sessions.NewCookieStore([]byte("test session secret"))))
+	e.GET("/signin/oidc", app.auth.OIDCSignIn)
+	e.GET("/signin/oidc/callback", app.auth.OIDCCallback)
+	e.POST("/account/password", app.auth.ChangePassword)
+	e.POST("/signin/2fa", app.auth.TwoFactorSignIn)
+	app.Server = httptest.NewServer(e)
+	t.Cleanup(app.Close)
+
+	app.auth.OIDC, err = NewOIDCProvider(context.Background(), "Mock", app.provider.URL, mockOIDCClientID, mockOIDCClientSecret, app.URL+"/signin/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+// newTestBrowser returns a client that keeps the session cookie and does not
+// follow redirects.
+func newTestBrowser(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// startSignIn opens the sign in link and returns where it redirects to.
+func (app *oidcTestApp) startSignIn(t *testing.T, browser *http.Client, path string) (authURL, state string) {
+	t.Helper()
+	resp, err := browser.Get(app.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("%s: status = %d, want a redirect", path, resp.StatusCode)
+	}
+	authURL = resp.Header.Get("Location")
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authURL, u.Query().Get("state")
+}
+
+// callback is the provider redirecting back, it returns the status, the
+// redirect location and the body of the answer.
+func (app *oidcTestApp) callback(t *testing.T, browser *http.Client, state, code string) (int, string, string) {
+	t.Helper()
+	resp, err := browser.Get(app.URL + "/signin/oidc/callback?" + url.Values{"state": {state}, "code": {code}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header.Get("Location"), string(body)
+}
+
+// signIn goes through the whole flow for grant and returns what the
+// callback answered.
+func (app *oidcTestApp) signIn(t *testing.T, browser *http.Client, path string, grant mockOIDCGrant) (int, string, string) {
+	t.Helper()
+	authURL, state := app.startSignIn(t, browser, path)
+	code := app.provider.authorize(t, authURL, grant)
+
+	return app.callback(t, browser, state, code)
+}
+
+func (app *oidcTestApp) identityUser(t *testing.T, subject string) *sqlc.User {
+	t.Helper()
+	user, err := app.auth.Db.GetUserByIdentity(context.Background(), sqlc.GetUserByIdentityParams{Issuer: app.provider.URL, Subject: subject})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &user
+}
+
+func TestOIDCSignInCreatesAccount(t *testing.T) {
+	app := newOIDCTestApp(t)
+
+	status, location, _ := app.signIn(t, newTestBrowser(t), "/signin/oidc", mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true})
+	if status != http.StatusFound || location != "/" {
+		t.Fatalf("status, location = %d, %q, want a redirect to /", status, location)
+	}
+
+	user := app.identityUser(t, "alice")
+	if user == nil || user.Email != "alice@example.com" {
+		t.Fatalf("identity user = %+v, want a new account for alice@example.com", user)
+	}
+	// Nobody knows the password of the account.
+	if app.auth.checkPassword(user, "") == nil {
+		t.Error("the account has an empty password")
+	}
+}
+
+func TestOIDCSignInLinksVerifiedEmail(t *testing.T) {
+	app := newOIDCTestApp(t)
+	existing, err := app.auth.signUp("bob@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, location, _ := app.signIn(t, newTestBrowser(t), "/signin/oidc", mockOIDCGrant{subject: "bob", email: "bob@example.com", emailVerified: true})
+	if status != http.StatusFound || location != "/" {
+		t.Fatalf("status, location = %d, %q, want a redirect to /", status, location)
+	}
+
+	if user := app.identityUser(t, "bob"); user == nil || user.ID != existing.ID {
+		t.Errorf("identity user = %+v, want the existing account %d", user, existing.ID)
+	}
+}
+
+func TestOIDCSignInDoesNotLinkUnverifiedEmail(t *testing.T) {
+	app := newOIDCTestApp(t)
+	if _, err := app.auth.signUp("bob@example.com", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, _, body := app.signIn(t, newTestBrowser(t), "/signin/oidc", mockOIDCGrant{subject: "mallory", email: "bob@example.com", emailVerified: false})
+	if status != http.StatusOK || !strings.Contains(body, "did not return a verified email address") {
+		t.Errorf("status = %d, want the sign in page saying the email is not verified", status)
+	}
+	if user := app.identityUser(t, "mallory"); user != nil {
+		t.Errorf("identity user = %+v, want none", user)
+	}
+}
+
+func TestOIDCSignInChecksState(t *testing.T) {
+	app := newOIDCTestApp(t)
+	browser := newTestBrowser(t)
+	authURL, state := app.startSignIn(t, browser, "/signin/oidc")
+	code := app.provider.authorize(t, authURL, mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true})
+
+	status, _, body := app.callback(t, browser, "forged", code)
+	if status != http.StatusOK || !strings.Contains(body, errOIDCSignIn.Error()) {
+		t.Errorf("status = %d, want the sign in page with an error", status)
+	}
+	if app.provider.tokenRequests != 0 {
+		t.Errorf("token requests = %d, want the code not exchanged", app.provider.tokenRequests)
+	}
+
+	// The state is used up by the first callback, whatever it brought.
+	status, _, body = app.callback(t, browser, state, code)
+	if status != http.StatusOK || !strings.Contains(body, errOIDCSignIn.Error()) {
+		t.Errorf("status = %d, want the sign in page with an error for a used state", status)
+	}
+	if user := app.identityUser(t, "alice"); user != nil {
+		t.Errorf("identity user = %+v, want none", user)
+	}
+}
+
+func TestOIDCSignInChecksNonce(t *testing.T) {
+	app := newOIDCTestApp(t)
+
+	status, _, body := app.signIn(t, newTestBrowser(t), "/signin/oidc", mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true, nonce: "replayed"})
+	if status != http.StatusOK || !strings.Contains(body, errOIDCSignIn.Error()) {
+		t.Errorf("status = %d, want the sign in page with an error", status)
+	}
+	if user := app.identityUser(t, "alice"); user != nil {
+		t.Errorf("identity user = %+v, want none", user)
+	}
+}
+
+// A code handed out for the sign in of one browser is useless in another,
+// as only the first knows the verifier for its challenge.
+func TestOIDCSignInUsesPKCE(t *testing.T) {
+	app := newOIDCTestApp(t)
+	victim, attacker := newTestBrowser(t), newTestBrowser(t)
+	victimURL, _ := app.startSignIn(t, victim, "/signin/oidc")
+	_, attackerState := app.startSignIn(t, attacker, "/signin/oidc")
+	code := app.provider.authorize(t, victimURL, mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true})
+
+	status, _, body := app.callback(t, attacker, attackerState, code)
+	if status != http.StatusOK || !strings.Contains(body, errOIDCSignIn.Error()) {
+		t.Errorf("status = %d, want the sign in page with an error", status)
+	}
+	if app.provider.tokenRequests == 0 {
+		t.Error("no token requests, want the exchange refused by the provider")
+	}
+	if user := app.identityUser(t, "alice"); user != nil {
+		t.Errorf("identity user = %+v, want none", user)
+	}
+}
+
+func TestOIDCReauthenticationReplacesCurrentPassword(t *testing.T) {
+	app := newOIDCTestApp(t)
+	browser := newTestBrowser(t)
+	grant := mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true}
+	if status, _, _ := app.signIn(t, browser, "/signin/oidc", grant); status != http.StatusFound {
+		t.Fatalf("sign in status = %d, want a redirect", status)
+	}
+
+	changePassword := func() string {
+		t.Helper()
+		resp, err := browser.PostForm(app.URL+"/account/password", url.Values{"password1": {"new password"}, "password2": {"new password"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	if body := changePassword(); !strings.Contains(body, errWrongPassword.Error()) {
+		t.Fatal("password changed without the current password or signing in again")
+	}
+
+	// Signing in again with an identity of another account confirms nothing.
+	status, _, body := app.signIn(t, browser, "/signin/oidc?reauth=1", mockOIDCGrant{subject: "bob", email: "bob@example.com", emailVerified: true})
+	if status != http.StatusOK || !strings.Contains(body, errOIDCReauth.Error()) {
+		t.Errorf("status = %d, want the account page with an error", status)
+	}
+	if user := app.identityUser(t, "bob"); user != nil {
+		t.Errorf("identity user = %+v, want nothing linked or created", user)
+	}
+	if body := changePassword(); !strings.Contains(body, errWrongPassword.Error()) {
+		t.Fatal("password changed after signing in again as somebody else")
+	}
+
+	status, location, _ := app.signIn(t, browser, "/signin/oidc?reauth=1", grant)
+	if status != http.StatusFound || location != "/account" {
+		t.Fatalf("status, location = %d, %q, want a redirect to /account", status, location)
+	}
+	if body := changePassword(); !strings.Contains(body, "Your password has been changed.") {
+		t.Fatal("password not changed after signing in again")
+	}
+	if _, err := app.auth.signIn("alice@example.com", "new password", "192.0.2.1"); err != nil {
+		t.Errorf("signing in with the new password: %v", err)
+	}
+}
+
+// Every round trip through the identity provider must not reset the lockout
+// of the second step, or codes could be guessed without end.
+func TestOIDCSignInKeepsTwoFactorLockout(t *testing.T) {
+	app := newOIDCTestApp(t)
+	browser := newTestBrowser(t)
+	grant := mockOIDCGrant{subject: "alice", email: "alice@example.com", emailVerified: true}
+	if status, _, _ := app.signIn(t, browser, "/signin/oidc", grant); status != http.StatusFound {
+		t.Fatalf("sign in status = %d, want a redirect", status)
+	}
+	user := app.identityUser(t, "alice")
+	enrollment, err := app.auth.startTwoFactorEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.auth.enableTwoFactor(user.ID, code); err != nil {
+		t.Fatal(err)
+	}
+
+	secondFactor := func(code string) string {
+		t.Helper()
+		resp, err := browser.PostForm(app.URL+"/signin/2fa", url.Values{"code": {code}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	for i := range maxFailedSignInsPerAccount {
+		status, location, _ := app.signIn(t, browser, "/signin/oidc", grant)
+		if status != http.StatusFound || location != "/signin/2fa" {
+			t.Fatalf("round %d: status, location = %d, %q, want a redirect to /signin/2fa", i, status, location)
+		}
+		if body := secondFactor("000000"); !strings.Contains(body, errInvalidCode.Error()) {
+			t.Fatalf("round %d: wrong code not refused", i)
+		}
+	}
+
+	if status, location, _ := app.signIn(t, browser, "/signin/oidc", grant); status != http.StatusFound || location != "/signin/2fa" {
+		t.Fatalf("status, location = %d, %q, want a redirect to /signin/2fa", status, location)
+	}
+	code, err = totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := secondFactor(code); !strings.Contains(body, errTooManyAttempts.Error()) {
+		t.Error("second step not locked after signing in with the identity provider again")
+	}
+}
@@ -37,35 +37,425 @@ RETURNING *;
 SELECT 
 	id,
 	email,
-	hashed_password
+	hashed_password,
+	is_admin,
+	disabled,
+	session_generation
 FROM 
 	users 
 WHERE
 	email = ?
 ;
 
+-- name: GetUserByID :one
+SELECT 
+	id,
+	email,
+	hashed_password,
+	is_admin,
+	disabled,
+	session_generation
+FROM 
+	users 
+WHERE
+	id = ?
+;
+
+-- name: ListUsers :many
+SELECT 
+	u.id,
+	u.email,
+	u.is_admin,
+	u.disabled,
+	COALESCE(t.enabled, 0) AS two_factor_enabled
+FROM 
+	users u
+	LEFT JOIN user_totp t ON t.user_id = u.id
+ORDER BY
+	u.id
+;
+
+-- name: PromoteUser :exec
+UPDATE users SET is_admin = 1 WHERE email = ?;
+
+-- name: SetUserDisabled :exec
+UPDATE users SET disabled = ? WHERE id = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = ?, session_generation = session_generation + 1 WHERE id = ?;
+
+-- name: UpdateUserEmail :exec
+UPDATE users SET email = ? WHERE id = ?;
+
 -- name: DeleteUser :exec
 DELETE FROM users where id = ?;
 
--- name: CreateSession :one
-INSERT INTO user_sessions (
-	id,
-	user_id
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempts (
+	email,
+	ip,
+	success,
+	created
+) VALUES (
+	?, ?, ?, ?
+);
+
+-- name: CountFailedLoginAttemptsByEmail :one
+SELECT
+	COUNT(*)
+FROM
+	login_attempts a
+WHERE
+	a.email = @email
+	AND a.success = 0
+	AND a.created > @since
+	AND a.created > COALESCE((
+		SELECT MAX(created) FROM login_attempts la WHERE la.email = @email AND la.success = 1
+	), '')
+;
+
+-- name: CountFailedLoginAttemptsByIP :one
+SELECT
+	COUNT(*)
+FROM
+	login_attempts
+WHERE
+	ip = @ip
+	AND success = 0
+	AND created > @since
+;
+
+-- name: GetUserTOTP :one
+SELECT
+	user_id,
+	secret,
+	enabled,
+	created
+FROM
+	user_totp
+WHERE
+	user_id = ?
+;
+
+-- name: SaveUserTOTP :exec
+INSERT OR REPLACE INTO user_totp (
+	user_id,
+	secret,
+	enabled,
+	created
+) VALUES (
+	?, ?, 0, ?
+);
+
+-- name: EnableUserTOTP :exec
+UPDATE user_totp SET enabled = 1 WHERE user_id = ?;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = ?;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (
+	user_id,
+	hashed_code
 ) VALUES (
 	?, ?
+);
+
+-- name: GetUnusedRecoveryCodes :many
+SELECT
+	id,
+	hashed_code
+FROM
+	user_recovery_codes
+WHERE
+	user_id = ?
+	AND used IS NULL
+;
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes SET used = ? WHERE id = ? AND used IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes WHERE user_id = ?;
+
+-- name: GetUserByIdentity :one
+SELECT
+	u.id,
+	u.email,
+	u.hashed_password,
+	u.is_admin,
+	u.disabled,
+	u.session_generation
+FROM
+	users u
+	JOIN user_identities i ON i.user_id = u.id
+WHERE
+	i.issuer = ?
+	AND i.subject = ?
+;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+	issuer,
+	subject,
+	user_id,
+	email,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+);
+
+-- name: CreateNotification :exec
+INSERT INTO notifications (
+	former_name,
+	emails,
+	error,
+	created
+) VALUES (
+	?, ?, ?, ?
+);
+
+-- name: GetRecentNotifications :many
+SELECT
+	id,
+	former_name,
+	emails,
+	error,
+	created
+FROM
+	notifications
+ORDER BY
+	created DESC
+LIMIT ?
+;
+
+-- name: CountUserIdentities :one
+SELECT COUNT(*) FROM user_identities WHERE user_id = ? AND issuer = ?;
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities WHERE user_id = ?;
+
+-- name: AdoptUnownedFormerNames :execrows
+UPDATE former_names SET user_id = @user_id
+WHERE user_id IS NULL AND name_key NOT IN (
+	SELECT name_key FROM former_names WHERE user_id = @user_id AND name_key IS NOT NULL
+);
+
+-- name: CountUnownedFormerNames :one
+SELECT COUNT(*) FROM former_names WHERE user_id IS NULL;
+
+-- name: DeleteUserFormerNames :exec
+DELETE FROM former_names WHERE user_id = ?;
+
+-- name: CreateEmailVerification :exec
+INSERT INTO email_verifications (
+	hashed_token,
+	user_id,
+	email,
+	expires
+) VALUES (
+	?, ?, ?, ?
+);
+
+-- name: GetEmailVerification :one
+SELECT
+	hashed_token,
+	user_id,
+	email,
+	expires
+FROM
+	email_verifications
+WHERE
+	hashed_token = ?
+;
+
+-- name: DeleteEmailVerifications :exec
+DELETE FROM email_verifications WHERE user_id = ?;
+
+-- name: CreateStatusChange :exec
+INSERT INTO status_changes (
+	user_id,
+	name,
+	old_status,
+	new_status,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+);
+
+-- name: GetStatusChanges :many
+SELECT
+	id,
+	user_id,
+	name,
+	old_status,
+	new_status,
+	created
+FROM
+	status_changes
+WHERE
+	user_id = ?
+	AND name = ?
+ORDER BY
+	created DESC
+;
+
+-- name: DeleteUserStatusChanges :exec
+DELETE FROM status_changes WHERE user_id = ?;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+	user_id,
+	name,
+	hashed_token,
+	scope,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
 )
 RETURNING *;
 
--- name: DeleteSession :exec
-DELETE FROM user_sessions where id = ?;
+-- name: ListUserAPITokens :many
+SELECT
+	*
+FROM
+	api_tokens
+WHERE
+	user_id = ?
+ORDER BY
+	created DESC
+;
 
--- name: GetSession :one
-SELECT 
-	id,
-	user_id
+-- name: GetAPIToken :one
+SELECT
+	*
+FROM
+	api_tokens
+WHERE
+	hashed_token = ?
+	AND revoked IS NULL
+;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used = ? WHERE id = ?;
+
+-- name: RevokeAPIToken :execrows
+UPDATE api_tokens SET revoked = ? WHERE id = ? AND user_id = ? AND revoked IS NULL;
+
+-- name: DeleteUserAPITokens :exec
+DELETE FROM api_tokens WHERE user_id = ?;
+
+-- name: GetCachedResponse :one
+SELECT
+	*
 FROM
-	user_sessions
+	response_cache
+WHERE
+	url = ?
+;
+
+-- name: PutCachedResponse :exec
+INSERT OR REPLACE INTO response_cache (
+	url,
+	status_code,
+	body,
+	etag,
+	last_modified,
+	fetched
+) VALUES (
+	?, ?, ?, ?, ?, ?
+);
+
+-- name: DeleteCachedResponse :exec
+DELETE FROM response_cache WHERE url = ?;
+
+-- name: DeleteCachedResponsesBefore :exec
+DELETE FROM response_cache WHERE fetched < ?;
+
+-- name: ListWatchedCharacters :many
+SELECT
+	*
+FROM
+	watched_characters
+;
+
+-- name: ListUserWatchedCharacters :many
+SELECT
+	*
+FROM
+	watched_characters
+WHERE
+	user_id = ?
+ORDER BY
+	name
+;
+
+-- name: GetWatchedCharacter :one
+SELECT
+	*
+FROM
+	watched_characters
+WHERE
+	user_id = ?
+	AND name_key = ?
+;
+
+-- name: CreateWatchedCharacter :exec
+INSERT INTO watched_characters (
+	user_id,
+	name,
+	name_key,
+	world,
+	notification_emails,
+	last_checked
+) VALUES (
+	?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateWatchedCharacter :exec
+UPDATE watched_characters SET
+	name = ?,
+	name_key = ?,
+	world = ?,
+	last_checked = ?,
+	last_renamed = ?
 WHERE
 	id = ?
-LIMIT 1
 ;
+
+-- name: DeleteWatchedCharacter :execrows
+DELETE FROM watched_characters WHERE user_id = ? AND name_key = ?;
+
+-- name: DeleteWatchedCharacterByID :exec
+DELETE FROM watched_characters WHERE id = ?;
+
+-- name: DeleteUserWatchedCharacters :exec
+DELETE FROM watched_characters WHERE user_id = ?;
+
+-- name: CreateCharacterRename :exec
+INSERT INTO character_renames (
+	watched_character_id,
+	user_id,
+	old_name,
+	new_name,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+);
+
+-- name: MoveCharacterRenames :exec
+UPDATE character_renames SET watched_character_id = @to_id WHERE watched_character_id = @from_id;
+
+-- name: ListUserCharacterRenames :many
+SELECT
+	*
+FROM
+	character_renames
+WHERE
+	user_id = ?
+ORDER BY
+	created
+;
+
+-- name: DeleteUserCharacterRenames :exec
+DELETE FROM character_renames WHERE user_id = ?;
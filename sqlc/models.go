@@ -6,8 +6,36 @@ package sqlc
 
 import (
 	"database/sql"
+	"time"
 )
 
+type ApiToken struct {
+	ID          int64
+	UserID      int64
+	Name        string
+	HashedToken []byte
+	Scope       string
+	Created     time.Time
+	LastUsed    sql.NullTime
+	Revoked     sql.NullTime
+}
+
+type CharacterRename struct {
+	ID                 int64
+	WatchedCharacterID int64
+	UserID             int64
+	OldName            string
+	NewName            string
+	Created            time.Time
+}
+
+type EmailVerification struct {
+	HashedToken []byte
+	UserID      int64
+	Email       string
+	Expires     time.Time
+}
+
 type FormerName struct {
 	ID                 int64
 	Name               sql.NullString
@@ -15,16 +43,83 @@ type FormerName struct {
 	LastChecked        sql.NullTime
 	LastUpdatedStatus  sql.NullTime
 	Status             sql.NullString
+	UserID             sql.NullInt64
+	NameKey            sql.NullString
+	NotifyWhen         int64
+}
+
+type LoginAttempt struct {
+	ID      int64
+	Email   string
+	Ip      string
+	Success bool
+	Created time.Time
+}
+
+type Notification struct {
+	ID         int64
+	FormerName string
+	Emails     string
+	Error      sql.NullString
+	Created    time.Time
+}
+
+type ResponseCache struct {
+	Url          string
+	StatusCode   int64
+	Body         []byte
+	Etag         string
+	LastModified string
+	Fetched      time.Time
+}
+
+type StatusChange struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	OldStatus string
+	NewStatus string
+	Created   time.Time
 }
 
 type User struct {
-	ID             int64
-	Email          string
-	HashedPassword []byte
+	ID                int64
+	Email             string
+	HashedPassword    []byte
+	IsAdmin           bool
+	Disabled          bool
+	SessionGeneration int64
+}
+
+type UserIdentity struct {
+	Issuer  string
+	Subject string
+	UserID  int64
+	Email   string
+	Created time.Time
 }
 
-type UserSession struct {
-	ID      string
-	UserID  string
-	Created interface{}
+type UserRecoveryCode struct {
+	ID         int64
+	UserID     int64
+	HashedCode []byte
+	Used       sql.NullTime
+}
+
+type UserTotp struct {
+	UserID  int64
+	Secret  string
+	Enabled bool
+	Created time.Time
+}
+
+type WatchedCharacter struct {
+	ID                 int64
+	UserID             int64
+	Name               string
+	NameKey            string
+	World              string
+	NotificationEmails string
+	LastChecked        time.Time
+	LastRenamed        sql.NullTime
 }
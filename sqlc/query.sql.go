@@ -8,30 +8,312 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
-const createSession = `-- name: CreateSession :one
-INSERT INTO user_sessions (
-	id,
-	user_id
+const adoptUnownedFormerNames = `-- name: AdoptUnownedFormerNames :execrows
+UPDATE former_names SET user_id = ?1
+WHERE user_id IS NULL AND name_key NOT IN (
+	SELECT name_key FROM former_names WHERE user_id = ?1 AND name_key IS NOT NULL
+)
+`
+
+func (q *Queries) AdoptUnownedFormerNames(ctx context.Context, userID sql.NullInt64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, adoptUnownedFormerNames, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countFailedLoginAttemptsByEmail = `-- name: CountFailedLoginAttemptsByEmail :one
+SELECT
+	COUNT(*)
+FROM
+	login_attempts a
+WHERE
+	a.email = ?1
+	AND a.success = 0
+	AND a.created > ?2
+	AND a.created > COALESCE((
+		SELECT MAX(created) FROM login_attempts la WHERE la.email = ?1 AND la.success = 1
+	), '')
+`
+
+type CountFailedLoginAttemptsByEmailParams struct {
+	Email string
+	Since time.Time
+}
+
+func (q *Queries) CountFailedLoginAttemptsByEmail(ctx context.Context, arg CountFailedLoginAttemptsByEmailParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFailedLoginAttemptsByEmail, arg.Email, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFailedLoginAttemptsByIP = `-- name: CountFailedLoginAttemptsByIP :one
+;
+
+SELECT
+	COUNT(*)
+FROM
+	login_attempts
+WHERE
+	ip = ?1
+	AND success = 0
+	AND created > ?2
+`
+
+type CountFailedLoginAttemptsByIPParams struct {
+	Ip    string
+	Since time.Time
+}
+
+func (q *Queries) CountFailedLoginAttemptsByIP(ctx context.Context, arg CountFailedLoginAttemptsByIPParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFailedLoginAttemptsByIP, arg.Ip, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnownedFormerNames = `-- name: CountUnownedFormerNames :one
+SELECT COUNT(*) FROM former_names WHERE user_id IS NULL
+`
+
+func (q *Queries) CountUnownedFormerNames(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnownedFormerNames)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUserIdentities = `-- name: CountUserIdentities :one
+;
+
+SELECT COUNT(*) FROM user_identities WHERE user_id = ? AND issuer = ?
+`
+
+type CountUserIdentitiesParams struct {
+	UserID int64
+	Issuer string
+}
+
+func (q *Queries) CountUserIdentities(ctx context.Context, arg CountUserIdentitiesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUserIdentities, arg.UserID, arg.Issuer)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+	user_id,
+	name,
+	hashed_token,
+	scope,
+	created
 ) VALUES (
-	?, ?
+	?, ?, ?, ?, ?
 )
-RETURNING id, user_id, created
+RETURNING id, user_id, name, hashed_token, scope, created, last_used, revoked
 `
 
-type CreateSessionParams struct {
-	ID     string
-	UserID string
+type CreateAPITokenParams struct {
+	UserID      int64
+	Name        string
+	HashedToken []byte
+	Scope       string
+	Created     time.Time
 }
 
-func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (UserSession, error) {
-	row := q.db.QueryRowContext(ctx, createSession, arg.ID, arg.UserID)
-	var i UserSession
-	err := row.Scan(&i.ID, &i.UserID, &i.Created)
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.UserID,
+		arg.Name,
+		arg.HashedToken,
+		arg.Scope,
+		arg.Created,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.HashedToken,
+		&i.Scope,
+		&i.Created,
+		&i.LastUsed,
+		&i.Revoked,
+	)
 	return i, err
 }
 
+const createCharacterRename = `-- name: CreateCharacterRename :exec
+INSERT INTO character_renames (
+	watched_character_id,
+	user_id,
+	old_name,
+	new_name,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+)
+`
+
+type CreateCharacterRenameParams struct {
+	WatchedCharacterID int64
+	UserID             int64
+	OldName            string
+	NewName            string
+	Created            time.Time
+}
+
+func (q *Queries) CreateCharacterRename(ctx context.Context, arg CreateCharacterRenameParams) error {
+	_, err := q.db.ExecContext(ctx, createCharacterRename,
+		arg.WatchedCharacterID,
+		arg.UserID,
+		arg.OldName,
+		arg.NewName,
+		arg.Created,
+	)
+	return err
+}
+
+const createEmailVerification = `-- name: CreateEmailVerification :exec
+INSERT INTO email_verifications (
+	hashed_token,
+	user_id,
+	email,
+	expires
+) VALUES (
+	?, ?, ?, ?
+)
+`
+
+type CreateEmailVerificationParams struct {
+	HashedToken []byte
+	UserID      int64
+	Email       string
+	Expires     time.Time
+}
+
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) error {
+	_, err := q.db.ExecContext(ctx, createEmailVerification,
+		arg.HashedToken,
+		arg.UserID,
+		arg.Email,
+		arg.Expires,
+	)
+	return err
+}
+
+const createLoginAttempt = `-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempts (
+	email,
+	ip,
+	success,
+	created
+) VALUES (
+	?, ?, ?, ?
+)
+`
+
+type CreateLoginAttemptParams struct {
+	Email   string
+	Ip      string
+	Success bool
+	Created time.Time
+}
+
+func (q *Queries) CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, createLoginAttempt,
+		arg.Email,
+		arg.Ip,
+		arg.Success,
+		arg.Created,
+	)
+	return err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO notifications (
+	former_name,
+	emails,
+	error,
+	created
+) VALUES (
+	?, ?, ?, ?
+)
+`
+
+type CreateNotificationParams struct {
+	FormerName string
+	Emails     string
+	Error      sql.NullString
+	Created    time.Time
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createNotification,
+		arg.FormerName,
+		arg.Emails,
+		arg.Error,
+		arg.Created,
+	)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (
+	user_id,
+	hashed_code
+) VALUES (
+	?, ?
+)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID     int64
+	HashedCode []byte
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.HashedCode)
+	return err
+}
+
+const createStatusChange = `-- name: CreateStatusChange :exec
+INSERT INTO status_changes (
+	user_id,
+	name,
+	old_status,
+	new_status,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+)
+`
+
+type CreateStatusChangeParams struct {
+	UserID    int64
+	Name      string
+	OldStatus string
+	NewStatus string
+	Created   time.Time
+}
+
+func (q *Queries) CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createStatusChange,
+		arg.UserID,
+		arg.Name,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.Created,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
 	email,
@@ -39,7 +321,7 @@ INSERT INTO users (
 ) VALUES (
 	?, ?
 )
-RETURNING id, email, hashed_password
+RETURNING id, email, hashed_password, is_admin, disabled, session_generation
 `
 
 type CreateUserParams struct {
@@ -50,10 +332,115 @@ type CreateUserParams struct {
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
 	var i User
-	err := row.Scan(&i.ID, &i.Email, &i.HashedPassword)
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.SessionGeneration,
+	)
 	return i, err
 }
 
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+;
+
+INSERT INTO user_identities (
+	issuer,
+	subject,
+	user_id,
+	email,
+	created
+) VALUES (
+	?, ?, ?, ?, ?
+)
+`
+
+type CreateUserIdentityParams struct {
+	Issuer  string
+	Subject string
+	UserID  int64
+	Email   string
+	Created time.Time
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, createUserIdentity,
+		arg.Issuer,
+		arg.Subject,
+		arg.UserID,
+		arg.Email,
+		arg.Created,
+	)
+	return err
+}
+
+const createWatchedCharacter = `-- name: CreateWatchedCharacter :exec
+;
+
+INSERT INTO watched_characters (
+	user_id,
+	name,
+	name_key,
+	world,
+	notification_emails,
+	last_checked
+) VALUES (
+	?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateWatchedCharacterParams struct {
+	UserID             int64
+	Name               string
+	NameKey            string
+	World              string
+	NotificationEmails string
+	LastChecked        time.Time
+}
+
+func (q *Queries) CreateWatchedCharacter(ctx context.Context, arg CreateWatchedCharacterParams) error {
+	_, err := q.db.ExecContext(ctx, createWatchedCharacter,
+		arg.UserID,
+		arg.Name,
+		arg.NameKey,
+		arg.World,
+		arg.NotificationEmails,
+		arg.LastChecked,
+	)
+	return err
+}
+
+const deleteCachedResponse = `-- name: DeleteCachedResponse :exec
+DELETE FROM response_cache WHERE url = ?
+`
+
+func (q *Queries) DeleteCachedResponse(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, deleteCachedResponse, url)
+	return err
+}
+
+const deleteCachedResponsesBefore = `-- name: DeleteCachedResponsesBefore :exec
+DELETE FROM response_cache WHERE fetched < ?
+`
+
+func (q *Queries) DeleteCachedResponsesBefore(ctx context.Context, fetched time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteCachedResponsesBefore, fetched)
+	return err
+}
+
+const deleteEmailVerifications = `-- name: DeleteEmailVerifications :exec
+;
+
+DELETE FROM email_verifications WHERE user_id = ?
+`
+
+func (q *Queries) DeleteEmailVerifications(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteEmailVerifications, userID)
+	return err
+}
+
 const deleteFormerName = `-- name: DeleteFormerName :exec
 DELETE FROM former_names WHERE name = ?
 `
@@ -63,18 +450,16 @@ func (q *Queries) DeleteFormerName(ctx context.Context, name sql.NullString) err
 	return err
 }
 
-const deleteSession = `-- name: DeleteSession :exec
-DELETE FROM user_sessions where id = ?
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes WHERE user_id = ?
 `
 
-func (q *Queries) DeleteSession(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteSession, id)
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
 	return err
 }
 
 const deleteUser = `-- name: DeleteUser :exec
-;
-
 DELETE FROM users where id = ?
 `
 
@@ -83,6 +468,185 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteUserAPITokens = `-- name: DeleteUserAPITokens :exec
+DELETE FROM api_tokens WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserAPITokens(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserAPITokens, userID)
+	return err
+}
+
+const deleteUserCharacterRenames = `-- name: DeleteUserCharacterRenames :exec
+;
+
+DELETE FROM character_renames WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserCharacterRenames(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserCharacterRenames, userID)
+	return err
+}
+
+const deleteUserFormerNames = `-- name: DeleteUserFormerNames :exec
+DELETE FROM former_names WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserFormerNames(ctx context.Context, userID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserFormerNames, userID)
+	return err
+}
+
+const deleteUserIdentities = `-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserIdentities(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserIdentities, userID)
+	return err
+}
+
+const deleteUserStatusChanges = `-- name: DeleteUserStatusChanges :exec
+;
+
+DELETE FROM status_changes WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserStatusChanges(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserStatusChanges, userID)
+	return err
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserTOTP, userID)
+	return err
+}
+
+const deleteUserWatchedCharacters = `-- name: DeleteUserWatchedCharacters :exec
+DELETE FROM watched_characters WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserWatchedCharacters(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserWatchedCharacters, userID)
+	return err
+}
+
+const deleteWatchedCharacter = `-- name: DeleteWatchedCharacter :execrows
+;
+
+DELETE FROM watched_characters WHERE user_id = ? AND name_key = ?
+`
+
+type DeleteWatchedCharacterParams struct {
+	UserID  int64
+	NameKey string
+}
+
+func (q *Queries) DeleteWatchedCharacter(ctx context.Context, arg DeleteWatchedCharacterParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWatchedCharacter, arg.UserID, arg.NameKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWatchedCharacterByID = `-- name: DeleteWatchedCharacterByID :exec
+DELETE FROM watched_characters WHERE id = ?
+`
+
+func (q *Queries) DeleteWatchedCharacterByID(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWatchedCharacterByID, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE user_totp SET enabled = 1 WHERE user_id = ?
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, enableUserTOTP, userID)
+	return err
+}
+
+const getAPIToken = `-- name: GetAPIToken :one
+;
+
+SELECT
+	id, user_id, name, hashed_token, scope, created, last_used, revoked
+FROM
+	api_tokens
+WHERE
+	hashed_token = ?
+	AND revoked IS NULL
+`
+
+func (q *Queries) GetAPIToken(ctx context.Context, hashedToken []byte) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPIToken, hashedToken)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.HashedToken,
+		&i.Scope,
+		&i.Created,
+		&i.LastUsed,
+		&i.Revoked,
+	)
+	return i, err
+}
+
+const getCachedResponse = `-- name: GetCachedResponse :one
+SELECT
+	url, status_code, body, etag, last_modified, fetched
+FROM
+	response_cache
+WHERE
+	url = ?
+`
+
+func (q *Queries) GetCachedResponse(ctx context.Context, url string) (ResponseCache, error) {
+	row := q.db.QueryRowContext(ctx, getCachedResponse, url)
+	var i ResponseCache
+	err := row.Scan(
+		&i.Url,
+		&i.StatusCode,
+		&i.Body,
+		&i.Etag,
+		&i.LastModified,
+		&i.Fetched,
+	)
+	return i, err
+}
+
+const getEmailVerification = `-- name: GetEmailVerification :one
+SELECT
+	hashed_token,
+	user_id,
+	email,
+	expires
+FROM
+	email_verifications
+WHERE
+	hashed_token = ?
+`
+
+func (q *Queries) GetEmailVerification(ctx context.Context, hashedToken []byte) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, getEmailVerification, hashedToken)
+	var i EmailVerification
+	err := row.Scan(
+		&i.HashedToken,
+		&i.UserID,
+		&i.Email,
+		&i.Expires,
+	)
+	return i, err
+}
+
 const getFormerNames = `-- name: GetFormerNames :many
 SELECT 
 	name,
@@ -131,35 +695,149 @@ func (q *Queries) GetFormerNames(ctx context.Context) ([]GetFormerNamesRow, erro
 	return items, nil
 }
 
-const getSession = `-- name: GetSession :one
-SELECT 
+const getRecentNotifications = `-- name: GetRecentNotifications :many
+SELECT
 	id,
-	user_id
+	former_name,
+	emails,
+	error,
+	created
 FROM
-	user_sessions
-WHERE
-	id = ?
-LIMIT 1
+	notifications
+ORDER BY
+	created DESC
+LIMIT ?
 `
 
-type GetSessionRow struct {
-	ID     string
-	UserID string
-}
-
-func (q *Queries) GetSession(ctx context.Context, id string) (GetSessionRow, error) {
-	row := q.db.QueryRowContext(ctx, getSession, id)
-	var i GetSessionRow
-	err := row.Scan(&i.ID, &i.UserID)
-	return i, err
-}
-
-const getUser = `-- name: GetUser :one
-SELECT 
-	id,
-	email,
-	hashed_password
-FROM 
+func (q *Queries) GetRecentNotifications(ctx context.Context, limit int64) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentNotifications, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.FormerName,
+			&i.Emails,
+			&i.Error,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStatusChanges = `-- name: GetStatusChanges :many
+SELECT
+	id,
+	user_id,
+	name,
+	old_status,
+	new_status,
+	created
+FROM
+	status_changes
+WHERE
+	user_id = ?
+	AND name = ?
+ORDER BY
+	created DESC
+`
+
+type GetStatusChangesParams struct {
+	UserID int64
+	Name   string
+}
+
+func (q *Queries) GetStatusChanges(ctx context.Context, arg GetStatusChangesParams) ([]StatusChange, error) {
+	rows, err := q.db.QueryContext(ctx, getStatusChanges, arg.UserID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StatusChange
+	for rows.Next() {
+		var i StatusChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnusedRecoveryCodes = `-- name: GetUnusedRecoveryCodes :many
+SELECT
+	id,
+	hashed_code
+FROM
+	user_recovery_codes
+WHERE
+	user_id = ?
+	AND used IS NULL
+`
+
+type GetUnusedRecoveryCodesRow struct {
+	ID         int64
+	HashedCode []byte
+}
+
+func (q *Queries) GetUnusedRecoveryCodes(ctx context.Context, userID int64) ([]GetUnusedRecoveryCodesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnusedRecoveryCodes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnusedRecoveryCodesRow
+	for rows.Next() {
+		var i GetUnusedRecoveryCodesRow
+		if err := rows.Scan(&i.ID, &i.HashedCode); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+SELECT 
+	id,
+	email,
+	hashed_password,
+	is_admin,
+	disabled,
+	session_generation
+FROM 
 	users 
 WHERE
 	email = ?
@@ -168,10 +846,441 @@ WHERE
 func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUser, email)
 	var i User
-	err := row.Scan(&i.ID, &i.Email, &i.HashedPassword)
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.SessionGeneration,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+;
+
+SELECT 
+	id,
+	email,
+	hashed_password,
+	is_admin,
+	disabled,
+	session_generation
+FROM 
+	users 
+WHERE
+	id = ?
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.SessionGeneration,
+	)
 	return i, err
 }
 
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT
+	u.id,
+	u.email,
+	u.hashed_password,
+	u.is_admin,
+	u.disabled,
+	u.session_generation
+FROM
+	users u
+	JOIN user_identities i ON i.user_id = u.id
+WHERE
+	i.issuer = ?
+	AND i.subject = ?
+`
+
+type GetUserByIdentityParams struct {
+	Issuer  string
+	Subject string
+}
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIdentity, arg.Issuer, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.SessionGeneration,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+;
+
+SELECT
+	user_id,
+	secret,
+	enabled,
+	created
+FROM
+	user_totp
+WHERE
+	user_id = ?
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, userID int64) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, getUserTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.Enabled,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getWatchedCharacter = `-- name: GetWatchedCharacter :one
+;
+
+SELECT
+	id, user_id, name, name_key, world, notification_emails, last_checked, last_renamed
+FROM
+	watched_characters
+WHERE
+	user_id = ?
+	AND name_key = ?
+`
+
+type GetWatchedCharacterParams struct {
+	UserID  int64
+	NameKey string
+}
+
+func (q *Queries) GetWatchedCharacter(ctx context.Context, arg GetWatchedCharacterParams) (WatchedCharacter, error) {
+	row := q.db.QueryRowContext(ctx, getWatchedCharacter, arg.UserID, arg.NameKey)
+	var i WatchedCharacter
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.NameKey,
+		&i.World,
+		&i.NotificationEmails,
+		&i.LastChecked,
+		&i.LastRenamed,
+	)
+	return i, err
+}
+
+const listUserAPITokens = `-- name: ListUserAPITokens :many
+SELECT
+	id, user_id, name, hashed_token, scope, created, last_used, revoked
+FROM
+	api_tokens
+WHERE
+	user_id = ?
+ORDER BY
+	created DESC
+`
+
+func (q *Queries) ListUserAPITokens(ctx context.Context, userID int64) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAPITokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.HashedToken,
+			&i.Scope,
+			&i.Created,
+			&i.LastUsed,
+			&i.Revoked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserCharacterRenames = `-- name: ListUserCharacterRenames :many
+SELECT
+	id, watched_character_id, user_id, old_name, new_name, created
+FROM
+	character_renames
+WHERE
+	user_id = ?
+ORDER BY
+	created
+`
+
+func (q *Queries) ListUserCharacterRenames(ctx context.Context, userID int64) ([]CharacterRename, error) {
+	rows, err := q.db.QueryContext(ctx, listUserCharacterRenames, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CharacterRename
+	for rows.Next() {
+		var i CharacterRename
+		if err := rows.Scan(
+			&i.ID,
+			&i.WatchedCharacterID,
+			&i.UserID,
+			&i.OldName,
+			&i.NewName,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserWatchedCharacters = `-- name: ListUserWatchedCharacters :many
+;
+
+SELECT
+	id, user_id, name, name_key, world, notification_emails, last_checked, last_renamed
+FROM
+	watched_characters
+WHERE
+	user_id = ?
+ORDER BY
+	name
+`
+
+func (q *Queries) ListUserWatchedCharacters(ctx context.Context, userID int64) ([]WatchedCharacter, error) {
+	rows, err := q.db.QueryContext(ctx, listUserWatchedCharacters, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WatchedCharacter
+	for rows.Next() {
+		var i WatchedCharacter
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.NameKey,
+			&i.World,
+			&i.NotificationEmails,
+			&i.LastChecked,
+			&i.LastRenamed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+;
+
+SELECT 
+	u.id,
+	u.email,
+	u.is_admin,
+	u.disabled,
+	COALESCE(t.enabled, 0) AS two_factor_enabled
+FROM 
+	users u
+	LEFT JOIN user_totp t ON t.user_id = u.id
+ORDER BY
+	u.id
+`
+
+type ListUsersRow struct {
+	ID               int64
+	Email            string
+	IsAdmin          bool
+	Disabled         bool
+	TwoFactorEnabled bool
+}
+
+func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.IsAdmin,
+			&i.Disabled,
+			&i.TwoFactorEnabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchedCharacters = `-- name: ListWatchedCharacters :many
+SELECT
+	id, user_id, name, name_key, world, notification_emails, last_checked, last_renamed
+FROM
+	watched_characters
+`
+
+func (q *Queries) ListWatchedCharacters(ctx context.Context) ([]WatchedCharacter, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchedCharacters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WatchedCharacter
+	for rows.Next() {
+		var i WatchedCharacter
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.NameKey,
+			&i.World,
+			&i.NotificationEmails,
+			&i.LastChecked,
+			&i.LastRenamed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveCharacterRenames = `-- name: MoveCharacterRenames :exec
+UPDATE character_renames SET watched_character_id = ?1 WHERE watched_character_id = ?2
+`
+
+type MoveCharacterRenamesParams struct {
+	ToID   int64
+	FromID int64
+}
+
+func (q *Queries) MoveCharacterRenames(ctx context.Context, arg MoveCharacterRenamesParams) error {
+	_, err := q.db.ExecContext(ctx, moveCharacterRenames, arg.ToID, arg.FromID)
+	return err
+}
+
+const promoteUser = `-- name: PromoteUser :exec
+;
+
+UPDATE users SET is_admin = 1 WHERE email = ?
+`
+
+func (q *Queries) PromoteUser(ctx context.Context, email string) error {
+	_, err := q.db.ExecContext(ctx, promoteUser, email)
+	return err
+}
+
+const putCachedResponse = `-- name: PutCachedResponse :exec
+;
+
+INSERT OR REPLACE INTO response_cache (
+	url,
+	status_code,
+	body,
+	etag,
+	last_modified,
+	fetched
+) VALUES (
+	?, ?, ?, ?, ?, ?
+)
+`
+
+type PutCachedResponseParams struct {
+	Url          string
+	StatusCode   int64
+	Body         []byte
+	Etag         string
+	LastModified string
+	Fetched      time.Time
+}
+
+func (q *Queries) PutCachedResponse(ctx context.Context, arg PutCachedResponseParams) error {
+	_, err := q.db.ExecContext(ctx, putCachedResponse,
+		arg.Url,
+		arg.StatusCode,
+		arg.Body,
+		arg.Etag,
+		arg.LastModified,
+		arg.Fetched,
+	)
+	return err
+}
+
+const revokeAPIToken = `-- name: RevokeAPIToken :execrows
+UPDATE api_tokens SET revoked = ? WHERE id = ? AND user_id = ? AND revoked IS NULL
+`
+
+type RevokeAPITokenParams struct {
+	Revoked sql.NullTime
+	ID      int64
+	UserID  int64
+}
+
+func (q *Queries) RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIToken, arg.Revoked, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const saveFormerName = `-- name: SaveFormerName :exec
 INSERT OR REPLACE INTO former_names (
 	id, 
@@ -204,3 +1313,136 @@ func (q *Queries) SaveFormerName(ctx context.Context, arg SaveFormerNameParams)
 	)
 	return err
 }
+
+const saveUserTOTP = `-- name: SaveUserTOTP :exec
+;
+
+INSERT OR REPLACE INTO user_totp (
+	user_id,
+	secret,
+	enabled,
+	created
+) VALUES (
+	?, ?, 0, ?
+)
+`
+
+type SaveUserTOTPParams struct {
+	UserID  int64
+	Secret  string
+	Created time.Time
+}
+
+func (q *Queries) SaveUserTOTP(ctx context.Context, arg SaveUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, saveUserTOTP, arg.UserID, arg.Secret, arg.Created)
+	return err
+}
+
+const setUserDisabled = `-- name: SetUserDisabled :exec
+UPDATE users SET disabled = ? WHERE id = ?
+`
+
+type SetUserDisabledParams struct {
+	Disabled bool
+	ID       int64
+}
+
+func (q *Queries) SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) error {
+	_, err := q.db.ExecContext(ctx, setUserDisabled, arg.Disabled, arg.ID)
+	return err
+}
+
+const touchAPIToken = `-- name: TouchAPIToken :exec
+;
+
+UPDATE api_tokens SET last_used = ? WHERE id = ?
+`
+
+type TouchAPITokenParams struct {
+	LastUsed sql.NullTime
+	ID       int64
+}
+
+func (q *Queries) TouchAPIToken(ctx context.Context, arg TouchAPITokenParams) error {
+	_, err := q.db.ExecContext(ctx, touchAPIToken, arg.LastUsed, arg.ID)
+	return err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :exec
+UPDATE users SET email = ? WHERE id = ?
+`
+
+type UpdateUserEmailParams struct {
+	Email string
+	ID    int64
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = ?, session_generation = session_generation + 1 WHERE id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	HashedPassword []byte
+	ID             int64
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.HashedPassword, arg.ID)
+	return err
+}
+
+const updateWatchedCharacter = `-- name: UpdateWatchedCharacter :exec
+UPDATE watched_characters SET
+	name = ?,
+	name_key = ?,
+	world = ?,
+	last_checked = ?,
+	last_renamed = ?
+WHERE
+	id = ?
+`
+
+type UpdateWatchedCharacterParams struct {
+	Name        string
+	NameKey     string
+	World       string
+	LastChecked time.Time
+	LastRenamed sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) UpdateWatchedCharacter(ctx context.Context, arg UpdateWatchedCharacterParams) error {
+	_, err := q.db.ExecContext(ctx, updateWatchedCharacter,
+		arg.Name,
+		arg.NameKey,
+		arg.World,
+		arg.LastChecked,
+		arg.LastRenamed,
+		arg.ID,
+	)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+;
+
+UPDATE user_recovery_codes SET used = ? WHERE id = ? AND used IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	Used sql.NullTime
+	ID   int64
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.Used, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,55 @@
+// Package tibiacom reads character pages of the official website, for when
+// no TibiaData instance can be reached. Characters are returned in the
+// models of the tibiadata package, limited to what the page shows.
+package tibiacom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"strings"
+)
+
+// Pages larger than this are not read, character pages are around 50 KiB.
+const maxPageSize = 2 << 20
+
+type Client struct {
+	BaseURL string
+	// HTTPClient sends the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Character looks name up like the character search of the website, which
+// also finds characters by a former name. Unknown names return a
+// tibiadata.StatusError for which tibiadata.IsNotFound is true.
+func (c *Client) Character(ctx context.Context, name string) (*tibiadata.CharacterInfo, error) {
+	query := url.Values{"subtopic": {"characters"}, "name": {name}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/community/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", tibiadata.ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &tibiadata.StatusError{HTTPCode: resp.StatusCode}
+	}
+
+	return ParseCharacter(io.LimitReader(resp.Body, maxPageSize))
+}
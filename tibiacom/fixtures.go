@@ -0,0 +1,47 @@
+package tibiacom
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+)
+
+// notFoundPage is the part of the page the website shows for unknown names
+// that ParseCharacter looks at.
+const notFoundPage = `<div class="TableContainer"><div class="CaptionContainer"><div class="CaptionInnerContainer"><div class="Text">Could not find character</div></div></div></div>`
+
+// FixtureTransport answers character searches with saved pages instead of
+// calling the website, for running offline. The search for Bubble is
+// answered with characters/Bubble.html from FS. Names without a saved page
+// get the box the website shows for unknown names. See testdata for saved
+// pages.
+type FixtureTransport struct {
+	FS fs.FS
+}
+
+func (t FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	body, err := fs.ReadFile(t.FS, "characters/"+req.URL.Query().Get("name")+".html")
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+		body = []byte(notFoundPage)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
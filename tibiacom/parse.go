@@ -0,0 +1,179 @@
+package tibiacom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoCharacterInformation is returned for pages that neither show a
+// character nor say that it does not exist, usually because the layout of
+// the website changed. It matches tibiadata.ErrUpstream.
+var ErrNoCharacterInformation = fmt.Errorf("%w: no character information on tibia.com page", tibiadata.ErrUpstream)
+
+// notFoundText is the caption of the box shown instead of the character
+// information for unknown names, maintenanceText part of the page shown
+// while the game servers are down.
+const (
+	notFoundText    = "Could not find character"
+	maintenanceText = "maintenance"
+)
+
+// ParseCharacter reads a character page. The information is laid out as
+// table rows of a "Label:" cell followed by a value cell, the first row
+// with a label wins so that the character information table takes
+// precedence over the account information further down.
+func ParseCharacter(r io.Reader) (*tibiadata.CharacterInfo, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenance := false
+	var captions []string
+	fields := map[string]string{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Div && hasClass(n, "CaptionContainer") {
+			captions = append(captions, text(n))
+		}
+		if n.Type == html.TextNode && strings.Contains(strings.ToLower(n.Data), maintenanceText) {
+			maintenance = true
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Tr {
+			cells := childElements(n, atom.Td)
+			if len(cells) == 2 {
+				label := text(cells[0])
+				if strings.HasSuffix(label, ":") {
+					label = strings.TrimSuffix(label, ":")
+					if _, ok := fields[label]; !ok {
+						fields[label] = text(cells[1])
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	// Only the caption of a box counts, and only on pages without a
+	// character, as the comment of a character can say anything.
+	if fields["Name"] == "" && slices.Contains(captions, notFoundText) {
+		return nil, &tibiadata.StatusError{
+			HTTPCode: http.StatusNotFound,
+			Code:     tibiadata.CodeCharacterNotFound,
+			Message:  "could not find character",
+		}
+	}
+	if fields["Name"] == "" && maintenance {
+		return nil, &tibiadata.StatusError{HTTPCode: http.StatusServiceUnavailable, Message: "tibia.com is under maintenance"}
+	}
+	if fields["Name"] == "" {
+		return nil, ErrNoCharacterInformation
+	}
+
+	return &tibiadata.CharacterInfo{Character: character(fields)}, nil
+}
+
+func character(fields map[string]string) tibiadata.Character {
+	c := tibiadata.Character{
+		Name:          fields["Name"],
+		FormerNames:   splitList(fields["Former Names"]),
+		Sex:           fields["Sex"],
+		Title:         fields["Title"],
+		Vocation:      fields["Vocation"],
+		World:         fields["World"],
+		FormerWorlds:  splitList(fields["Former World"]),
+		Residence:     fields["Residence"],
+		MarriedTo:     fields["Married To"],
+		LastLogin:     fields["Last Login"],
+		Position:      fields["Position"],
+		AccountStatus: fields["Account Status"],
+		Comment:       fields["Comment"],
+	}
+
+	// Names read "Bubble, will be deleted at Feb 20 2025, 10:00:00 CET" or
+	// "Bubble (traded)".
+	if name, date, ok := strings.Cut(c.Name, ", will be deleted at "); ok {
+		c.Name, c.DeletionDate = name, date
+	}
+	if name, ok := strings.CutSuffix(c.Name, " (traded)"); ok {
+		c.Name, c.Traded = name, true
+	}
+
+	c.Level, _ = strconv.Atoi(fields["Level"])
+	c.AchievementPoints, _ = strconv.Atoi(fields["Achievement Points"])
+	if title, unlocked, ok := strings.Cut(c.Title, " ("); ok {
+		c.Title = title
+		c.UnlockedTitles, _ = strconv.Atoi(strings.TrimSuffix(unlocked, " titles unlocked)"))
+	}
+	// "Leader of the Red Rose"
+	if rank, guild, ok := strings.Cut(fields["Guild Membership"], " of the "); ok {
+		c.Guild = tibiadata.CharacterGuild{Name: guild, Rank: rank}
+	}
+
+	return c
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" && slices.Contains(strings.Fields(attr.Val), class) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func childElements(n *html.Node, a atom.Atom) []*html.Node {
+	var children []*html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == a {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// text returns the text of n with the non-breaking spaces the website uses
+// in labels and dates turned into spaces and runs of whitespace collapsed.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Br {
+			b.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	// strings.Fields also splits at non-breaking spaces.
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,136 @@
+package tibiacom
+
+import (
+	"errors"
+	"os"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parseFixture(t *testing.T, name string) (*tibiadata.CharacterInfo, error) {
+	t.Helper()
+	f, err := os.Open("testdata/characters/" + name + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	return ParseCharacter(f)
+}
+
+func TestParseCharacter(t *testing.T) {
+	info, err := parseFixture(t, "Bubble")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := info.Character
+	if c.Name != "Bubble" {
+		t.Errorf("Name = %q, want Bubble", c.Name)
+	}
+	if want := []string{"Old Bubble", "Bubble Tea"}; !slices.Equal(c.FormerNames, want) {
+		t.Errorf("FormerNames = %q, want %q", c.FormerNames, want)
+	}
+	if c.World != "Antica" || c.Level != 342 || c.AchievementPoints != 512 {
+		t.Errorf("World, Level, AchievementPoints = %q, %d, %d, want Antica, 342, 512", c.World, c.Level, c.AchievementPoints)
+	}
+	// The loyalty title of the account information must not replace the
+	// character title.
+	if c.Title != "Aspiring Knight" || c.UnlockedTitles != 12 {
+		t.Errorf("Title, UnlockedTitles = %q, %d, want Aspiring Knight, 12", c.Title, c.UnlockedTitles)
+	}
+	if c.Guild.Name != "Red Rose" || c.Guild.Rank != "Leader" {
+		t.Errorf("Guild = %+v, want Leader of the Red Rose", c.Guild)
+	}
+	if c.LastLogin != "Feb 18 2025, 19:30:20 CET" {
+		t.Errorf("LastLogin = %q, want non-breaking spaces turned into spaces", c.LastLogin)
+	}
+	if c.Comment != "Hi there! Looking for a team." {
+		t.Errorf("Comment = %q", c.Comment)
+	}
+	if c.Traded || c.DeletionDate != "" {
+		t.Errorf("Traded, DeletionDate = %v, %q, want false, empty", c.Traded, c.DeletionDate)
+	}
+}
+
+func TestParseCharacterByFormerName(t *testing.T) {
+	info, err := parseFixture(t, "Old Bubble")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Character.Name != "Bubble" {
+		t.Errorf("Name = %q, want the current name Bubble", info.Character.Name)
+	}
+	if !slices.Contains(info.Character.FormerNames, "Old Bubble") {
+		t.Errorf("FormerNames = %q, want Old Bubble among them", info.Character.FormerNames)
+	}
+}
+
+func TestParseCharacterNotFound(t *testing.T) {
+	_, err := parseFixture(t, "Free Name")
+
+	if !tibiadata.IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	var statusError *tibiadata.StatusError
+	if !errors.As(err, &statusError) || statusError.Code != tibiadata.CodeCharacterNotFound {
+		t.Errorf("err = %#v, want a StatusError with code %d", err, tibiadata.CodeCharacterNotFound)
+	}
+}
+
+func TestParseCharacterTradedAndDeleted(t *testing.T) {
+	info, err := parseFixture(t, "Cachero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := info.Character
+	if c.Name != "Cachero" {
+		t.Errorf("Name = %q, want the suffixes stripped", c.Name)
+	}
+	if !c.Traded {
+		t.Error("Traded = false, want true")
+	}
+	if c.DeletionDate != "Mar 01 2025, 10:00:00 CET" {
+		t.Errorf("DeletionDate = %q", c.DeletionDate)
+	}
+}
+
+// The owner of a character can write the not-found caption into its
+// comment, that must not make the name look free.
+func TestParseCharacterNotFoundTextInComment(t *testing.T) {
+	info, err := parseFixture(t, "Trickster")
+	if err != nil {
+		t.Fatalf("err = %v, want the character", err)
+	}
+
+	if info.Character.Name != "Trickster" {
+		t.Errorf("Name = %q, want Trickster", info.Character.Name)
+	}
+}
+
+func TestParseCharacterWithoutInformation(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want error
+	}{
+		{"maintenance", `<html><body><p>Tibia is currently under maintenance.</p></body></html>`, tibiadata.ErrMaintenance},
+		{"other page", `<html><body><p>Welcome to Tibia!</p></body></html>`, ErrNoCharacterInformation},
+		{"not-found text outside a caption", `<html><body><p>Could not find character</p></body></html>`, ErrNoCharacterInformation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCharacter(strings.NewReader(tt.page))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if tibiadata.IsNotFound(err) {
+				t.Errorf("err = %v, want anything but not found", err)
+			}
+		})
+	}
+}
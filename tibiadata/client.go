@@ -0,0 +1,334 @@
+// Package tibiadata is a client for the TibiaData v4 API
+// (https://docs.tibiadata.com).
+package tibiadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Responses larger than this are not read, the biggest ones (worlds with
+// many players online) stay well below.
+const maxResponseSize = 10 << 20
+
+// CodeCharacterNotFound is the information.status.error of a character
+// lookup for a name nobody uses.
+const CodeCharacterNotFound = 20001
+
+// TibiaData numbers its errors by kind, 1xxxx for input that fails
+// validation and 2xxxx for things that do not exist.
+const (
+	codeValidationMin = 10000
+	codeValidationMax = 19999
+)
+
+// The kinds of errors requests fail with, for errors.Is. A StatusError
+// matches the kind of its status, failed requests and unreadable responses
+// match ErrUpstream.
+var (
+	ErrNotFound    = errors.New("tibiadata: not found")
+	ErrInvalidName = errors.New("tibiadata: invalid name")
+	ErrRateLimited = errors.New("tibiadata: rate limited")
+	ErrMaintenance = errors.New("tibiadata: maintenance")
+	ErrUpstream    = errors.New("tibiadata: upstream error")
+)
+
+type Client struct {
+	BaseURL string
+	// HTTPClient sends the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// StatusError is returned when TibiaData answers with an error in
+// information.status or a status code other than 200.
+type StatusError struct {
+	HTTPCode int
+	// Code is TibiaData's own error code, 0 if there is none.
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.HTTPCode)
+	}
+	if e.Code != 0 {
+		return fmt.Sprintf("tibiadata: %d %s (error %d)", e.HTTPCode, message, e.Code)
+	}
+
+	return fmt.Sprintf("tibiadata: %d %s", e.HTTPCode, message)
+}
+
+// NotFound reports whether TibiaData says the requested character does not
+// exist. Only its own error code counts: a 404 without it comes from a proxy
+// or a wrongly configured URL and says nothing about the character.
+func (e *StatusError) NotFound() bool {
+	return e.Code == CodeCharacterNotFound
+}
+
+// Kind returns which of ErrNotFound, ErrInvalidName, ErrRateLimited,
+// ErrMaintenance and ErrUpstream the error is.
+func (e *StatusError) Kind() error {
+	switch {
+	case e.NotFound():
+		return ErrNotFound
+	case e.HTTPCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.HTTPCode == http.StatusServiceUnavailable || strings.Contains(strings.ToLower(e.Message), "maintenance"):
+		return ErrMaintenance
+	case e.HTTPCode == http.StatusBadRequest || e.Code >= codeValidationMin && e.Code <= codeValidationMax:
+		return ErrInvalidName
+	default:
+		return ErrUpstream
+	}
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == e.Kind()
+}
+
+// IsNotFound reports whether err is an error for something that does not
+// exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func (c *Client) Character(ctx context.Context, name string) (*CharacterInfo, error) {
+	var response struct {
+		Character CharacterInfo `json:"character"`
+	}
+	if err := c.get(ctx, &response, "character", name); err != nil {
+		return nil, err
+	}
+	if response.Character.Character.Name == "" {
+		return nil, fmt.Errorf("%w: response for %q has no character", ErrUpstream, name)
+	}
+
+	return &response.Character, nil
+}
+
+func (c *Client) World(ctx context.Context, name string) (*World, error) {
+	var response struct {
+		World World `json:"world"`
+	}
+	if err := c.get(ctx, &response, "world", name); err != nil {
+		return nil, err
+	}
+
+	return &response.World, nil
+}
+
+func (c *Client) Worlds(ctx context.Context) (*Worlds, error) {
+	var response struct {
+		Worlds Worlds `json:"worlds"`
+	}
+	if err := c.get(ctx, &response, "worlds"); err != nil {
+		return nil, err
+	}
+
+	return &response.Worlds, nil
+}
+
+func (c *Client) Guild(ctx context.Context, name string) (*Guild, error) {
+	var response struct {
+		Guild Guild `json:"guild"`
+	}
+	if err := c.get(ctx, &response, "guild", name); err != nil {
+		return nil, err
+	}
+
+	return &response.Guild, nil
+}
+
+func (c *Client) Guilds(ctx context.Context, world string) (*Guilds, error) {
+	var response struct {
+		Guilds Guilds `json:"guilds"`
+	}
+	if err := c.get(ctx, &response, "guilds", world); err != nil {
+		return nil, err
+	}
+
+	return &response.Guilds, nil
+}
+
+// Highscores returns one page of 50 entries. World "all" covers every world,
+// vocation "all" every vocation. Categories are named like "experience" or
+// "magiclevel".
+func (c *Client) Highscores(ctx context.Context, world, category, vocation string, page int) (*Highscores, error) {
+	var response struct {
+		Highscores Highscores `json:"highscores"`
+	}
+	if err := c.get(ctx, &response, "highscores", world, category, vocation, strconv.Itoa(page)); err != nil {
+		return nil, err
+	}
+
+	return &response.Highscores, nil
+}
+
+func (c *Client) Houses(ctx context.Context, world, town string) (*Houses, error) {
+	var response struct {
+		Houses Houses `json:"houses"`
+	}
+	if err := c.get(ctx, &response, "houses", world, town); err != nil {
+		return nil, err
+	}
+
+	return &response.Houses, nil
+}
+
+func (c *Client) House(ctx context.Context, world string, houseID int) (*House, error) {
+	var response struct {
+		House House `json:"house"`
+	}
+	if err := c.get(ctx, &response, "house", world, strconv.Itoa(houseID)); err != nil {
+		return nil, err
+	}
+
+	return &response.House, nil
+}
+
+func (c *Client) KillStatistics(ctx context.Context, world string) (*KillStatistics, error) {
+	var response struct {
+		KillStatistics KillStatistics `json:"killstatistics"`
+	}
+	if err := c.get(ctx, &response, "killstatistics", world); err != nil {
+		return nil, err
+	}
+
+	return &response.KillStatistics, nil
+}
+
+func (c *Client) BoostableBosses(ctx context.Context) (*BoostableBosses, error) {
+	var response struct {
+		BoostableBosses BoostableBosses `json:"boostable_bosses"`
+	}
+	if err := c.get(ctx, &response, "boostablebosses"); err != nil {
+		return nil, err
+	}
+
+	return &response.BoostableBosses, nil
+}
+
+func (c *Client) Creatures(ctx context.Context) (*Creatures, error) {
+	var response struct {
+		Creatures Creatures `json:"creatures"`
+	}
+	if err := c.get(ctx, &response, "creatures"); err != nil {
+		return nil, err
+	}
+
+	return &response.Creatures, nil
+}
+
+// Creature looks a creature up by its race, the lowercase name used in
+// CreatureEntry.Race.
+func (c *Client) Creature(ctx context.Context, race string) (*Creature, error) {
+	var response struct {
+		Creature Creature `json:"creature"`
+	}
+	if err := c.get(ctx, &response, "creature", race); err != nil {
+		return nil, err
+	}
+
+	return &response.Creature, nil
+}
+
+// NewsLatest returns the news of the last 90 days.
+func (c *Client) NewsLatest(ctx context.Context) ([]NewsEntry, error) {
+	return c.newsList(ctx, "latest")
+}
+
+func (c *Client) NewsTicker(ctx context.Context) ([]NewsEntry, error) {
+	return c.newsList(ctx, "newsticker")
+}
+
+// NewsArchive returns the news and articles of the last days.
+func (c *Client) NewsArchive(ctx context.Context, days int) ([]NewsEntry, error) {
+	return c.newsList(ctx, "archive", strconv.Itoa(days))
+}
+
+func (c *Client) newsList(ctx context.Context, segments ...string) ([]NewsEntry, error) {
+	var response struct {
+		News []NewsEntry `json:"news"`
+	}
+	if err := c.get(ctx, &response, append([]string{"news"}, segments...)...); err != nil {
+		return nil, err
+	}
+
+	return response.News, nil
+}
+
+func (c *Client) News(ctx context.Context, id int) (*News, error) {
+	var response struct {
+		News News `json:"news"`
+	}
+	if err := c.get(ctx, &response, "news", "id", strconv.Itoa(id)); err != nil {
+		return nil, err
+	}
+
+	return &response.News, nil
+}
+
+// get requests /v4/ followed by the escaped segments and decodes the body
+// into out, unless information.status reports an error.
+func (c *Client) get(ctx context.Context, out any, segments ...string) error {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/v4/"+strings.Join(escaped, "/"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpstream, err)
+	}
+
+	var envelope struct {
+		Information Information `json:"information"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &StatusError{HTTPCode: resp.StatusCode}
+		}
+		return fmt.Errorf("%w: decoding %s: %w", ErrUpstream, req.URL.Path, err)
+	}
+	status := envelope.Information.Status
+	if status.HTTPCode == 0 {
+		status.HTTPCode = resp.StatusCode
+	}
+	if status.Error != 0 || status.HTTPCode != http.StatusOK || resp.StatusCode != http.StatusOK {
+		return &StatusError{HTTPCode: status.HTTPCode, Code: status.Error, Message: status.Message}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: decoding %s: %w", ErrUpstream, req.URL.Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package tibiadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func fixtureClient() *Client {
+	c := NewClient("http://fixtures")
+	c.HTTPClient = &http.Client{Transport: FixtureTransport{FS: os.DirFS("testdata")}}
+
+	return c
+}
+
+// Every endpoint decodes its recording, checked by one field deep enough in
+// the response that a wrong JSON tag on the way would leave it empty.
+func TestClientDecodesRecordings(t *testing.T) {
+	ctx := context.Background()
+	c := fixtureClient()
+	tests := []struct {
+		name string
+		get  func() (any, error)
+		want any
+	}{
+		{"character", func() (any, error) {
+			r, err := c.Character(ctx, "Bubble")
+			if err != nil {
+				return nil, err
+			}
+			return fmt.Sprintf("%s %q %d %s", r.Character.Name, r.Character.FormerNames, r.Character.Level, r.Character.Houses[0].Name), nil
+		}, `Bubble ["Old Bubble" "Bubble Tea"] 348 Upper Swamp Lane 8`},
+		{"world", func() (any, error) {
+			r, err := c.World(ctx, "Antica")
+			if err != nil {
+				return nil, err
+			}
+			return r.PlayersOnline, nil
+		}, 412},
+		{"worlds", func() (any, error) {
+			r, err := c.Worlds(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return r.RegularWorlds[0].Name, nil
+		}, "Antica"},
+		{"guild", func() (any, error) {
+			r, err := c.Guild(ctx, "Red Rose")
+			if err != nil {
+				return nil, err
+			}
+			return r.World, nil
+		}, "Antica"},
+		{"guilds", func() (any, error) {
+			r, err := c.Guilds(ctx, "Antica")
+			if err != nil {
+				return nil, err
+			}
+			return r.Active[0].Name, nil
+		}, "Red Rose"},
+		{"highscores", func() (any, error) {
+			r, err := c.Highscores(ctx, "Antica", "experience", "all", 1)
+			if err != nil {
+				return nil, err
+			}
+			return r.HighscoreList[0].Name, nil
+		}, "Arieswar"},
+		{"houses", func() (any, error) {
+			r, err := c.Houses(ctx, "Antica", "Thais")
+			if err != nil {
+				return nil, err
+			}
+			return r.HouseList[0].HouseID, nil
+		}, 10101},
+		{"house", func() (any, error) {
+			r, err := c.House(ctx, "Antica", 10101)
+			if err != nil {
+				return nil, err
+			}
+			return r.Name, nil
+		}, "Upper Swamp Lane 8"},
+		{"kill statistics", func() (any, error) {
+			r, err := c.KillStatistics(ctx, "Antica")
+			if err != nil {
+				return nil, err
+			}
+			return r.Entries[0].LastWeekKilled, nil
+		}, 10811},
+		{"boostable bosses", func() (any, error) {
+			r, err := c.BoostableBosses(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return r.Boosted.Name, nil
+		}, "Grand Master Oberon"},
+		{"creatures", func() (any, error) {
+			r, err := c.Creatures(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return r.Boosted.Race, nil
+		}, "demon"},
+		{"creature", func() (any, error) {
+			r, err := c.Creature(ctx, "demon")
+			if err != nil {
+				return nil, err
+			}
+			return r.Name, nil
+		}, "Demons"},
+		{"news latest", func() (any, error) {
+			r, err := c.NewsLatest(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return r[0].ID, nil
+		}, 6529},
+		{"news ticker", func() (any, error) {
+			r, err := c.NewsTicker(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return r[0].Type, nil
+		}, "ticker"},
+		{"news archive", func() (any, error) {
+			r, err := c.NewsArchive(ctx, 90)
+			if err != nil {
+				return nil, err
+			}
+			return len(r), nil
+		}, 2},
+		{"news", func() (any, error) {
+			r, err := c.News(ctx, 6529)
+			if err != nil {
+				return nil, err
+			}
+			return r.Title, nil
+		}, "Winter Update 2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCharacterNotFound(t *testing.T) {
+	_, err := fixtureClient().Character(context.Background(), "Free Name")
+
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	var statusError *StatusError
+	if !errors.As(err, &statusError) || statusError.HTTPCode != http.StatusNotFound || statusError.Code != CodeCharacterNotFound {
+		t.Errorf("err = %#v, want a StatusError 404 with code %d", err, CodeCharacterNotFound)
+	}
+}
+
+func TestClientStatusErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		httpCode int
+		// code and message make up the information.status of the response,
+		// unless raw is set, which is sent as the body as it is.
+		code    int
+		message string
+		raw     string
+		want    error
+	}{
+		{name: "character not found", httpCode: http.StatusNotFound, code: CodeCharacterNotFound, message: "could not find character", want: ErrNotFound},
+		{name: "name too long", httpCode: http.StatusBadRequest, code: 10004, message: "the provided character name is too long", want: ErrInvalidName},
+		{name: "validation code only", httpCode: http.StatusOK, code: 11005, message: "the provided world does not exist", want: ErrInvalidName},
+		{name: "bad request without JSON", httpCode: http.StatusBadRequest, raw: `Bad Request`, want: ErrInvalidName},
+		{name: "rate limited", httpCode: http.StatusTooManyRequests, message: "too many requests", want: ErrRateLimited},
+		{name: "rate limited without JSON", httpCode: http.StatusTooManyRequests, raw: `<html>Too Many Requests</html>`, want: ErrRateLimited},
+		{name: "maintenance", httpCode: http.StatusServiceUnavailable, message: "tibia.com is under maintenance", want: ErrMaintenance},
+		{name: "maintenance with 200", httpCode: http.StatusOK, code: 30001, message: "tibia.com is under maintenance", want: ErrMaintenance},
+		{name: "unavailable without JSON", httpCode: http.StatusServiceUnavailable, raw: `<html>Service Unavailable</html>`, want: ErrMaintenance},
+		{name: "404 without JSON", httpCode: http.StatusNotFound, raw: `404 page not found`, want: ErrUpstream},
+		{name: "guild not found", httpCode: http.StatusNotFound, code: 20004, message: "could not find guild", want: ErrUpstream},
+		{name: "house not found with 200", httpCode: http.StatusOK, code: 20005, message: "could not find house", want: ErrUpstream},
+		{name: "upstream error code", httpCode: http.StatusBadGateway, code: 30002, message: "could not fetch tibia.com", want: ErrUpstream},
+		{name: "internal server error without JSON", httpCode: http.StatusInternalServerError, raw: `Internal Server Error`, want: ErrUpstream},
+		{name: "bad gateway without JSON", httpCode: http.StatusBadGateway, raw: `<html>Bad Gateway</html>`, want: ErrUpstream},
+		{name: "gateway timeout without JSON", httpCode: http.StatusGatewayTimeout, raw: `<html>Gateway Timeout</html>`, want: ErrUpstream},
+		{name: "no character in response", httpCode: http.StatusOK, raw: `{"information":{"status":{"http_code":200}},"character":{"character":{}}}`, want: ErrUpstream},
+		{name: "unreadable response", httpCode: http.StatusOK, raw: `{"information":`, want: ErrUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.raw
+			if body == "" {
+				status := map[string]any{"http_code": tt.httpCode, "message": tt.message}
+				if tt.code != 0 {
+					status["error"] = tt.code
+				}
+				b, err := json.Marshal(map[string]any{"information": map[string]any{"status": status}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(b)
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.httpCode)
+				w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			_, err := NewClient(srv.URL).Character(context.Background(), "Bubble")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want != ErrNotFound && IsNotFound(err) {
+				t.Errorf("err = %v, want anything but not found", err)
+			}
+			// Only a 200 that cannot be read is no StatusError.
+			if tt.httpCode == http.StatusOK && tt.raw != "" {
+				return
+			}
+			var statusError *StatusError
+			if !errors.As(err, &statusError) || statusError.HTTPCode != tt.httpCode || statusError.Code != tt.code {
+				t.Errorf("err = %#v, want a StatusError %d with code %d", err, tt.httpCode, tt.code)
+			}
+		})
+	}
+}
+
+func TestClientRequestFailed(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	_, err := NewClient(srv.URL).Character(context.Background(), "Bubble")
+	if !errors.Is(err, ErrUpstream) {
+		t.Errorf("err = %v, want %v", err, ErrUpstream)
+	}
+}
+
+func TestClientEscapesPath(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.Write([]byte(`{"information":{"status":{"http_code":200}},"guild":{"name":"Red Rose"}}`))
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL+"/").Guild(context.Background(), "Red Rose/x"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/v4/guild/Red%20Rose%2Fx"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+}
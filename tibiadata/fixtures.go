@@ -0,0 +1,59 @@
+package tibiadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// FixtureTransport answers requests with recorded responses instead of
+// calling TibiaData, for running offline. The response to /v4/character/Bubble
+// is read from v4/character/Bubble.json in FS and gets the status code from
+// its information.status.http_code. Paths without a recording get a 404 like
+// TibiaData sends for unknown names. See testdata for recordings.
+type FixtureTransport struct {
+	FS fs.FS
+}
+
+func (t FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	name := strings.TrimPrefix(req.URL.Path, "/") + ".json"
+	body, err := fs.ReadFile(t.FS, name)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+		return fixtureResponse(req, http.StatusNotFound, []byte(`{"information":{"status":{"http_code":404,"error":20001,"message":"no recorded response"}}}`)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Information Information `json:"information"`
+	}
+	code := http.StatusOK
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Information.Status.HTTPCode != 0 {
+		code = envelope.Information.Status.HTTPCode
+	}
+
+	return fixtureResponse(req, code, body), nil
+}
+
+func fixtureResponse(req *http.Request, code int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(code),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,371 @@
+package tibiadata
+
+// Information is sent along with every response.
+type Information struct {
+	API struct {
+		Version int    `json:"version"`
+		Release string `json:"release"`
+		Commit  string `json:"commit"`
+	} `json:"api"`
+	Timestamp string   `json:"timestamp"`
+	TibiaURLs []string `json:"tibia_urls"`
+	Status    Status   `json:"status"`
+}
+
+type Status struct {
+	HTTPCode int    `json:"http_code"`
+	Error    int    `json:"error,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// CharacterInfo is everything the character page shows.
+type CharacterInfo struct {
+	Character          Character          `json:"character"`
+	Achievements       []Achievement      `json:"achievements"`
+	Deaths             []Death            `json:"deaths"`
+	DeathsTruncated    bool               `json:"deaths_truncated"`
+	AccountBadges      []AccountBadge     `json:"account_badges"`
+	AccountInformation AccountInformation `json:"account_information"`
+	OtherCharacters    []OtherCharacter   `json:"other_characters"`
+}
+
+type Character struct {
+	Name              string           `json:"name"`
+	FormerNames       []string         `json:"former_names"`
+	Traded            bool             `json:"traded"`
+	DeletionDate      string           `json:"deletion_date"`
+	Sex               string           `json:"sex"`
+	Title             string           `json:"title"`
+	UnlockedTitles    int              `json:"unlocked_titles"`
+	Vocation          string           `json:"vocation"`
+	Level             int              `json:"level"`
+	AchievementPoints int              `json:"achievement_points"`
+	World             string           `json:"world"`
+	FormerWorlds      []string         `json:"former_worlds"`
+	Residence         string           `json:"residence"`
+	MarriedTo         string           `json:"married_to"`
+	Houses            []CharacterHouse `json:"houses"`
+	Guild             CharacterGuild   `json:"guild"`
+	LastLogin         string           `json:"last_login"`
+	Position          string           `json:"position"`
+	AccountStatus     string           `json:"account_status"`
+	Comment           string           `json:"comment"`
+}
+
+type CharacterHouse struct {
+	Name    string `json:"name"`
+	Town    string `json:"town"`
+	Paid    string `json:"paid"`
+	HouseID int    `json:"houseid"`
+}
+
+type CharacterGuild struct {
+	Name string `json:"name"`
+	Rank string `json:"rank"`
+}
+
+type Achievement struct {
+	Name   string `json:"name"`
+	Grade  int    `json:"grade"`
+	Secret bool   `json:"secret"`
+}
+
+type Death struct {
+	Time    string   `json:"time"`
+	Level   int      `json:"level"`
+	Killers []Killer `json:"killers"`
+	Assists []Killer `json:"assists"`
+	Reason  string   `json:"reason"`
+}
+
+type Killer struct {
+	Name   string `json:"name"`
+	Player bool   `json:"player"`
+	Traded bool   `json:"traded"`
+	Summon string `json:"summon"`
+}
+
+type AccountBadge struct {
+	Name        string `json:"name"`
+	IconURL     string `json:"icon_url"`
+	Description string `json:"description"`
+}
+
+type AccountInformation struct {
+	Position     string `json:"position"`
+	Created      string `json:"created"`
+	LoyaltyTitle string `json:"loyalty_title"`
+}
+
+type OtherCharacter struct {
+	Name     string `json:"name"`
+	World    string `json:"world"`
+	Status   string `json:"status"`
+	Deleted  bool   `json:"deleted"`
+	Main     bool   `json:"main"`
+	Traded   bool   `json:"traded"`
+	Position string `json:"position"`
+}
+
+type World struct {
+	Name                string         `json:"name"`
+	Status              string         `json:"status"`
+	PlayersOnline       int            `json:"players_online"`
+	RecordPlayers       int            `json:"record_players"`
+	RecordDate          string         `json:"record_date"`
+	CreationDate        string         `json:"creation_date"`
+	Location            string         `json:"location"`
+	PvpType             string         `json:"pvp_type"`
+	PremiumOnly         bool           `json:"premium_only"`
+	TransferType        string         `json:"transfer_type"`
+	WorldQuestTitles    []string       `json:"world_quest_titles"`
+	BattleyeProtected   bool           `json:"battleye_protected"`
+	BattleyeDate        string         `json:"battleye_date"`
+	GameWorldType       string         `json:"game_world_type"`
+	TournamentWorldType string         `json:"tournament_world_type"`
+	OnlinePlayers       []OnlinePlayer `json:"online_players"`
+}
+
+type OnlinePlayer struct {
+	Name     string `json:"name"`
+	Level    int    `json:"level"`
+	Vocation string `json:"vocation"`
+}
+
+type Worlds struct {
+	PlayersOnline    int             `json:"players_online"`
+	RecordPlayers    int             `json:"record_players"`
+	RecordDate       string          `json:"record_date"`
+	RegularWorlds    []WorldOverview `json:"regular_worlds"`
+	TournamentWorlds []WorldOverview `json:"tournament_worlds"`
+}
+
+type WorldOverview struct {
+	Name                string `json:"name"`
+	Status              string `json:"status"`
+	PlayersOnline       int    `json:"players_online"`
+	Location            string `json:"location"`
+	PvpType             string `json:"pvp_type"`
+	PremiumOnly         bool   `json:"premium_only"`
+	TransferType        string `json:"transfer_type"`
+	BattleyeProtected   bool   `json:"battleye_protected"`
+	BattleyeDate        string `json:"battleye_date"`
+	GameWorldType       string `json:"game_world_type"`
+	TournamentWorldType string `json:"tournament_world_type"`
+}
+
+type Guild struct {
+	Name             string        `json:"name"`
+	World            string        `json:"world"`
+	LogoURL          string        `json:"logo_url"`
+	Description      string        `json:"description"`
+	Guildhalls       []Guildhall   `json:"guildhalls"`
+	Active           bool          `json:"active"`
+	Founded          string        `json:"founded"`
+	OpenApplications bool          `json:"open_applications"`
+	Homepage         string        `json:"homepage"`
+	InWar            bool          `json:"in_war"`
+	DisbandDate      string        `json:"disband_date"`
+	DisbandCondition string        `json:"disband_condition"`
+	PlayersOnline    int           `json:"players_online"`
+	PlayersOffline   int           `json:"players_offline"`
+	MembersTotal     int           `json:"members_total"`
+	MembersInvited   int           `json:"members_invited"`
+	Members          []GuildMember `json:"members"`
+	Invites          []GuildInvite `json:"invites"`
+}
+
+type Guildhall struct {
+	Name      string `json:"name"`
+	World     string `json:"world"`
+	PaidUntil string `json:"paid_until"`
+}
+
+type GuildMember struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Rank     string `json:"rank"`
+	Vocation string `json:"vocation"`
+	Level    int    `json:"level"`
+	Joined   string `json:"joined"`
+	Status   string `json:"status"`
+}
+
+type GuildInvite struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+}
+
+type Guilds struct {
+	World     string          `json:"world"`
+	Active    []GuildOverview `json:"active"`
+	Formation []GuildOverview `json:"formation"`
+}
+
+type GuildOverview struct {
+	Name        string `json:"name"`
+	LogoURL     string `json:"logo_url"`
+	Description string `json:"description"`
+}
+
+type Highscores struct {
+	World         string           `json:"world"`
+	Category      string           `json:"category"`
+	Vocation      string           `json:"vocation"`
+	HighscoreAge  int              `json:"highscore_age"`
+	HighscoreList []HighscoreEntry `json:"highscore_list"`
+	HighscorePage HighscorePage    `json:"highscore_page"`
+}
+
+type HighscoreEntry struct {
+	Rank     int    `json:"rank"`
+	Name     string `json:"name"`
+	Vocation string `json:"vocation"`
+	World    string `json:"world"`
+	Level    int    `json:"level"`
+	Value    int64  `json:"value"`
+	Title    string `json:"title"`
+}
+
+type HighscorePage struct {
+	CurrentPage  int `json:"current_page"`
+	TotalPages   int `json:"total_pages"`
+	TotalRecords int `json:"total_records"`
+}
+
+type Houses struct {
+	World         string          `json:"world"`
+	Town          string          `json:"town"`
+	HouseList     []HouseOverview `json:"house_list"`
+	GuildhallList []HouseOverview `json:"guildhall_list"`
+}
+
+type HouseOverview struct {
+	Name      string       `json:"name"`
+	HouseID   int          `json:"house_id"`
+	Size      int          `json:"size"`
+	Rent      int          `json:"rent"`
+	Rented    bool         `json:"rented"`
+	Auctioned bool         `json:"auctioned"`
+	Auction   HouseAuction `json:"auction"`
+}
+
+type HouseAuction struct {
+	CurrentBid int    `json:"current_bid"`
+	TimeLeft   string `json:"time_left"`
+	Finished   bool   `json:"finished"`
+}
+
+type House struct {
+	HouseID int         `json:"houseid"`
+	World   string      `json:"world"`
+	Town    string      `json:"town"`
+	Type    string      `json:"type"`
+	Name    string      `json:"name"`
+	Img     string      `json:"img"`
+	Beds    int         `json:"beds"`
+	Size    int         `json:"size"`
+	Rent    int         `json:"rent"`
+	Status  HouseStatus `json:"status"`
+}
+
+type HouseStatus struct {
+	IsAuctioned   bool         `json:"is_auctioned"`
+	IsRented      bool         `json:"is_rented"`
+	IsMoving      bool         `json:"is_moving"`
+	IsTransfering bool         `json:"is_transfering"`
+	Auction       HouseAuction `json:"auction"`
+	Rental        HouseRental  `json:"rental"`
+	Original      string       `json:"original"`
+}
+
+type HouseRental struct {
+	Owner            string `json:"owner"`
+	OwnerSex         string `json:"owner_sex"`
+	PaidUntil        string `json:"paid_until"`
+	MovingDate       string `json:"moving_date"`
+	TransferReceiver string `json:"transfer_receiver"`
+	TransferPrice    int    `json:"transfer_price"`
+	TransferAccept   bool   `json:"transfer_accept"`
+}
+
+type KillStatistics struct {
+	World   string               `json:"world"`
+	Entries []KillStatisticEntry `json:"entries"`
+	Total   KillStatisticEntry   `json:"total"`
+}
+
+// KillStatisticEntry has an empty Race in KillStatistics.Total.
+type KillStatisticEntry struct {
+	Race                  string `json:"race,omitempty"`
+	LastDayPlayersKilled  int    `json:"last_day_players_killed"`
+	LastDayKilled         int    `json:"last_day_killed"`
+	LastWeekPlayersKilled int    `json:"last_week_players_killed"`
+	LastWeekKilled        int    `json:"last_week_killed"`
+}
+
+type BoostableBosses struct {
+	Boosted           BossEntry   `json:"boosted"`
+	BoostableBossList []BossEntry `json:"boostable_boss_list"`
+}
+
+type BossEntry struct {
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+	Featured bool   `json:"featured"`
+}
+
+type Creatures struct {
+	Boosted      CreatureEntry   `json:"boosted"`
+	CreatureList []CreatureEntry `json:"creature_list"`
+}
+
+type CreatureEntry struct {
+	Name     string `json:"name"`
+	Race     string `json:"race"`
+	ImageURL string `json:"image_url"`
+	Featured bool   `json:"featured"`
+}
+
+type Creature struct {
+	Name             string   `json:"name"`
+	Race             string   `json:"race"`
+	ImageURL         string   `json:"image_url"`
+	Description      string   `json:"description"`
+	Behaviour        string   `json:"behaviour"`
+	Hitpoints        int      `json:"hitpoints"`
+	ImmuneTo         []string `json:"immune_to"`
+	StrongAgainst    []string `json:"strong_against"`
+	WeaknessAgainst  []string `json:"weakness_against"`
+	BeParalysed      bool     `json:"be_paralysed"`
+	BeSummoned       bool     `json:"be_summoned"`
+	SummonMana       int      `json:"summoned_mana"`
+	BeConvinced      bool     `json:"be_convinced"`
+	ConvincedMana    int      `json:"convinced_mana"`
+	SeeInvisible     bool     `json:"see_invisible"`
+	ExperiencePoints int      `json:"experience_points"`
+	IsLootable       bool     `json:"is_lootable"`
+	LootList         []string `json:"loot_list"`
+	Featured         bool     `json:"featured"`
+}
+
+type NewsEntry struct {
+	ID       int    `json:"id"`
+	Date     string `json:"date"`
+	News     string `json:"news"`
+	Category string `json:"category"`
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	URLAPI   string `json:"url_api"`
+}
+
+type News struct {
+	ID          int    `json:"id"`
+	Date        string `json:"date"`
+	Title       string `json:"title"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Content     string `json:"content"`
+	ContentHTML string `json:"content_html"`
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"net/http"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer        = "Tibia Buddy"
+	recoveryCodeCount = 10
+	// A password sign in has to be followed by the second step within this
+	// time, otherwise it has to start over.
+	pendingSignInTimeout = 5 * time.Minute
+)
+
+var errInvalidCode = errors.New("invalid authentication code")
+
+type TwoFactorEnrollment struct {
+	Secret    string
+	QRCodeURL string
+}
+
+func (a AuthService) twoFactorEnabled(userID int64) (bool, error) {
+	userTOTP, err := a.Db.GetUserTOTP(a.Ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return userTOTP.Enabled, nil
+}
+
+// startTwoFactorEnrollment returns the pending secret of the user along
+// with a QR code that authenticator apps can scan, generating the secret if
+// there is none yet. It is kept until enrollment succeeds, so reloading the
+// page or a mistyped code does not replace the secret the user scanned. The
+// secret is not used at sign in until enableTwoFactor confirms the user can
+// produce codes.
+func (a AuthService) startTwoFactorEnrollment(user *sqlc.User) (*TwoFactorEnrollment, error) {
+	opts := totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Email,
+	}
+	pending, err := a.Db.GetUserTOTP(a.Ctx, user.ID)
+	if err == nil {
+		opts.Secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(pending.Secret)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	key, err := totp.Generate(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Secret == nil {
+		err = a.Db.SaveUserTOTP(a.Ctx, sqlc.SaveUserTOTPParams{
+			UserID:  user.ID,
+			Secret:  key.Secret(),
+			Created: time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:    key.Secret(),
+		QRCodeURL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// enableTwoFactor turns on the pending secret once code is valid for it and
+// returns a fresh set of recovery codes. Only their hashes are stored, so
+// this is the only time they can be shown.
+func (a AuthService) enableTwoFactor(userID int64, code string) ([]string, error) {
+	userTOTP, err := a.Db.GetUserTOTP(a.Ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !totp.Validate(strings.TrimSpace(code), userTOTP.Secret) {
+		return nil, errInvalidCode
+	}
+
+	if err := a.Db.DeleteRecoveryCodes(a.Ctx, userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashedCode, err := bcrypt.GenerateFromPassword([]byte(normalizeRecoveryCode(codes[i])), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		err = a.Db.CreateRecoveryCode(a.Ctx, sqlc.CreateRecoveryCodeParams{UserID: userID, HashedCode: hashedCode})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, a.Db.EnableUserTOTP(a.Ctx, userID)
+}
+
+func (a AuthService) disableTwoFactor(userID int64, code string) error {
+	if err := a.verifySecondFactor(userID, code); err != nil {
+		return err
+	}
+	if err := a.Db.DeleteRecoveryCodes(a.Ctx, userID); err != nil {
+		return err
+	}
+
+	return a.Db.DeleteUserTOTP(a.Ctx, userID)
+}
+
+// verifySecondFactor accepts either a current TOTP code or one of the user's
+// unused recovery codes, which is spent on success.
+func (a AuthService) verifySecondFactor(userID int64, code string) error {
+	userTOTP, err := a.Db.GetUserTOTP(a.Ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !userTOTP.Enabled {
+		return errInvalidCode
+	}
+	if totp.Validate(strings.TrimSpace(code), userTOTP.Secret) {
+		return nil
+	}
+
+	recoveryCodes, err := a.Db.GetUnusedRecoveryCodes(a.Ctx, userID)
+	if err != nil {
+		return err
+	}
+	code = normalizeRecoveryCode(code)
+	for _, recoveryCode := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword(recoveryCode.HashedCode, []byte(code)) != nil {
+			continue
+		}
+		// Another request may have spent the code since it was read, only
+		// the one that marks it used gets in.
+		used, err := a.Db.UseRecoveryCode(a.Ctx, sqlc.UseRecoveryCodeParams{
+			Used: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:   recoveryCode.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if used == 0 {
+			return errInvalidCode
+		}
+		return nil
+	}
+
+	return errInvalidCode
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+
+	return strings.Join(strings.Fields(code), "")
+}
+
+// startSession signs the user in, or parks them on the second step when they
+// have two-factor authentication enabled.
+func (a *AuthService) startSession(c echo.Context, user *sqlc.User) error {
+	if user.Disabled {
+		return echo.NewHTTPError(http.StatusForbidden, "This account has been disabled.")
+	}
+	enabled, err := a.twoFactorEnabled(user.ID)
+	if err != nil {
+		return err
+	}
+
+	sess, _ := session.Get("session", c)
+	sess.Options = sessionOptions()
+	if enabled {
+		sess.Values["pending_user_id"] = user.ID
+		sess.Values["pending_since"] = time.Now().Unix()
+		sess.Save(c.Request(), c.Response())
+
+		return c.Redirect(http.StatusFound, "/signin/2fa")
+	}
+
+	setSessionUser(sess, user)
+	sess.Save(c.Request(), c.Response())
+
+	return c.Redirect(http.StatusFound, "/")
+}
+
+// setSessionUser signs user in with sess. The session lasts until the
+// session generation of the user changes, see currentUser.
+func setSessionUser(sess *sessions.Session, user *sqlc.User) {
+	sess.Values["user_id"] = user.ID
+	sess.Values["session_generation"] = user.SessionGeneration
+}
+
+func pendingUserID(c echo.Context) (int64, bool) {
+	sess, _ := session.Get("session", c)
+	userID, ok := sess.Values["pending_user_id"].(int64)
+	if !ok {
+		return 0, false
+	}
+	since, _ := sess.Values["pending_since"].(int64)
+	if time.Since(time.Unix(since, 0)) > pendingSignInTimeout {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func TwoFactorSignInPage(c echo.Context) error {
+	if _, ok := pendingUserID(c); !ok {
+		return c.Redirect(http.StatusFound, "/signin")
+	}
+
+	component := layout(twoFactorSignIn(nil), false)
+	return component.Render(c.Request().Context(), c.Response())
+}
+
+func (a *AuthService) TwoFactorSignIn(c echo.Context) error {
+	userID, ok := pendingUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/signin")
+	}
+
+	user, err := a.Db.GetUserByID(a.Ctx, userID)
+	if err != nil {
+		return err
+	}
+	ip := c.RealIP()
+	if err := a.checkSignInAttempts(user.Email, ip); err != nil {
+		errorMsg := err.Error()
+		component := layout(twoFactorSignIn(&errorMsg), false)
+		return component.Render(c.Request().Context(), c.Response())
+	}
+
+	err = a.verifySecondFactor(userID, c.FormValue("code"))
+	if err != nil {
+		if !errors.Is(err, errInvalidCode) {
+			return err
+		}
+		a.recordSignInAttempt(user.Email, ip, false)
+		errorMsg := err.Error()
+		component := layout(twoFactorSignIn(&errorMsg), false)
+		return component.Render(c.Request().Context(), c.Response())
+	}
+	a.recordSignInAttempt(user.Email, ip, true)
+
+	sess, _ := session.Get("session", c)
+	delete(sess.Values, "pending_user_id")
+	delete(sess.Values, "pending_since")
+	setSessionUser(sess, &user)
+	sess.Save(c.Request(), c.Response())
+
+	return c.Redirect(http.StatusFound, "/")
+}
+
+func (a *AuthService) TwoFactorPage(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	return a.renderTwoFactorPage(c, user, nil, nil)
+}
+
+func (a *AuthService) EnableTwoFactor(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	codes, err := a.enableTwoFactor(user.ID, c.FormValue("code"))
+	if err != nil {
+		if !errors.Is(err, errInvalidCode) && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		errorMsg := errInvalidCode.Error()
+		return a.renderTwoFactorPage(c, user, nil, &errorMsg)
+	}
+
+	return a.renderTwoFactorPage(c, user, codes, nil)
+}
+
+func (a *AuthService) DisableTwoFactor(c echo.Context) error {
+	user, err := a.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.disableTwoFactor(user.ID, c.FormValue("code"))
+	if err != nil {
+		if !errors.Is(err, errInvalidCode) {
+			return err
+		}
+		errorMsg := err.Error()
+		return a.renderTwoFactorPage(c, user, nil, &errorMsg)
+	}
+
+	return a.renderTwoFactorPage(c, user, nil, nil)
+}
+
+func (a *AuthService) renderTwoFactorPage(c echo.Context, user *sqlc.User, recoveryCodes []string, errorMsg *string) error {
+	enabled, err := a.twoFactorEnabled(user.ID)
+	if err != nil {
+		return err
+	}
+
+	var enrollment *TwoFactorEnrollment
+	if !enabled {
+		enrollment, err = a.startTwoFactorEnrollment(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	component := layout(twoFactorSettings(enabled, enrollment, recoveryCodes, errorMsg), true)
+	return component.Render(c.Request().Context(), c.Response())
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// enrollTestUser signs up a user with two-factor authentication enabled and
+// returns it with its secret and recovery codes.
+func enrollTestUser(t *testing.T, a *AuthService) (*sqlc.User, string, []string) {
+	t.Helper()
+	user, err := a.signUp("alice@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := a.startTwoFactorEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := a.enableTwoFactor(user.ID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user, enrollment.Secret, recoveryCodes
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	a := newTestAuthService(t)
+	user, err := a.signUp("alice@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enrollment, err := a.startTwoFactorEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.QRCodeURL, "data:image/png;base64,") {
+		t.Errorf("QR code URL = %.30q, want a PNG data URL", enrollment.QRCodeURL)
+	}
+	again, err := a.startTwoFactorEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Secret != enrollment.Secret {
+		t.Errorf("secret changed from %q to %q on reload", enrollment.Secret, again.Secret)
+	}
+	if enabled, err := a.twoFactorEnabled(user.ID); err != nil || enabled {
+		t.Fatalf("enabled, err = %v, %v before confirming a code, want false", enabled, err)
+	}
+
+	if _, err := a.enableTwoFactor(user.ID, "000000"); !errors.Is(err, errInvalidCode) {
+		t.Errorf("err = %v for a wrong code, want %v", err, errInvalidCode)
+	}
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := a.enableTwoFactor(user.ID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+	if enabled, err := a.twoFactorEnabled(user.ID); err != nil || !enabled {
+		t.Errorf("enabled, err = %v, %v after confirming a code, want true", enabled, err)
+	}
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		// code returns the code to sign in with from the secret and the
+		// recovery codes.
+		code func(t *testing.T, secret string, recoveryCodes []string) string
+		want error
+	}{
+		{"current code", func(t *testing.T, secret string, _ []string) string {
+			code, err := totp.GenerateCode(secret, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return " " + code + " "
+		}, nil},
+		{"expired code", func(t *testing.T, secret string, _ []string) string {
+			code, err := totp.GenerateCode(secret, time.Now().Add(-time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return code
+		}, errInvalidCode},
+		{"recovery code", func(t *testing.T, _ string, recoveryCodes []string) string {
+			return recoveryCodes[0]
+		}, nil},
+		{"recovery code in capitals with spaces", func(t *testing.T, _ string, recoveryCodes []string) string {
+			return strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", " "))
+		}, nil},
+		{"unknown recovery code", func(t *testing.T, _ string, _ []string) string {
+			return "aaaaa-aaaaa"
+		}, errInvalidCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthService(t)
+			user, secret, recoveryCodes := enrollTestUser(t, a)
+
+			if err := a.verifySecondFactor(user.ID, tt.code(t, secret, recoveryCodes)); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodeIsSpent(t *testing.T) {
+	a := newTestAuthService(t)
+	user, _, recoveryCodes := enrollTestUser(t, a)
+
+	if err := a.verifySecondFactor(user.ID, recoveryCodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.verifySecondFactor(user.ID, recoveryCodes[0]); !errors.Is(err, errInvalidCode) {
+		t.Errorf("err = %v using a recovery code again, want %v", err, errInvalidCode)
+	}
+	if err := a.verifySecondFactor(user.ID, recoveryCodes[1]); err != nil {
+		t.Errorf("err = %v using another recovery code, want nil", err)
+	}
+
+	// A request that read the code as unused before another one spent it
+	// must not get in as well.
+	unused, err := a.Db.GetUnusedRecoveryCodes(a.Ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	for _, want := range []int64{1, 0} {
+		n, err := a.Db.UseRecoveryCode(a.Ctx, sqlc.UseRecoveryCodeParams{Used: used, ID: unused[0].ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("spent %d codes, want %d", n, want)
+		}
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	a := newTestAuthService(t)
+	user, _, recoveryCodes := enrollTestUser(t, a)
+
+	if err := a.disableTwoFactor(user.ID, "000000"); !errors.Is(err, errInvalidCode) {
+		t.Fatalf("err = %v for a wrong code, want %v", err, errInvalidCode)
+	}
+	if err := a.disableTwoFactor(user.ID, recoveryCodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if enabled, err := a.twoFactorEnabled(user.ID); err != nil || enabled {
+		t.Errorf("enabled, err = %v, %v after disabling, want false", enabled, err)
+	}
+	if err := a.verifySecondFactor(user.ID, recoveryCodes[1]); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("err = %v using a recovery code after disabling, want %v", err, sql.ErrNoRows)
+	}
+}
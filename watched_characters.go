@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	errWatchedCharacterNotFound = errors.New("watched character not found")
+	errWatchedCharacterExists   = errors.New("character is already watched")
+	errCharacterNotFound        = errors.New("character not found")
+)
+
+// WatchedCharacter is a character followed across renames. Name is its
+// current name and FormerNames the names it had while watched, oldest first.
+type WatchedCharacter struct {
+	ID                int64      `json:"-"`
+	UserID            int64      `json:"-"`
+	Name              string     `json:"name"`
+	FormerNames       []string   `json:"former_names"`
+	World             string     `json:"world"`
+	NotificationEmail string     `json:"notification_email"`
+	LastChecked       time.Time  `json:"last_checked"`
+	LastRenamed       *time.Time `json:"last_renamed"`
+}
+
+func newWatchedCharacter(row sqlc.WatchedCharacter) WatchedCharacter {
+	watched := WatchedCharacter{
+		ID:                row.ID,
+		UserID:            row.UserID,
+		Name:              row.Name,
+		FormerNames:       []string{},
+		World:             row.World,
+		NotificationEmail: row.NotificationEmails,
+		LastChecked:       row.LastChecked,
+	}
+	if row.LastRenamed.Valid {
+		watched.LastRenamed = &row.LastRenamed.Time
+	}
+
+	return watched
+}
+
+// WatchedCharacterService holds what the HTML pages and the JSON API can do
+// with the characters a user watches.
+type WatchedCharacterService struct {
+	Queries *sqlc.Queries
+	Api     *TibiaDataApi
+}
+
+func NewWatchedCharacterService(db *repositoryClient, t *TibiaDataApi) *WatchedCharacterService {
+	return &WatchedCharacterService{
+		Queries: sqlc.New(db.Db),
+		Api:     t,
+	}
+}
+
+func (s *WatchedCharacterService) List(ctx context.Context, userID int64) ([]WatchedCharacter, error) {
+	rows, err := s.Queries.ListUserWatchedCharacters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	renames, err := s.Queries.ListUserCharacterRenames(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	formerNames := map[int64][]string{}
+	for _, rename := range renames {
+		formerNames[rename.WatchedCharacterID] = append(formerNames[rename.WatchedCharacterID], rename.OldName)
+	}
+	watched := make([]WatchedCharacter, len(rows))
+	for i, row := range rows {
+		watched[i] = newWatchedCharacter(row)
+		if names, ok := formerNames[row.ID]; ok {
+			watched[i].FormerNames = names
+		}
+	}
+
+	return watched, nil
+}
+
+// Watch looks name up and watches the character that has it, by its current
+// name when name is one of its former names.
+func (s *WatchedCharacterService) Watch(ctx context.Context, userID int64, name, notificationEmail string) (*WatchedCharacter, error) {
+	name = normalizeName(name)
+	if name == "" {
+		return nil, errFormerNameRequired
+	}
+
+	char, err := s.Api.SearchCharacter(ctx, name)
+	if err != nil {
+		return nil, searchError(name, err)
+	}
+	if !char.Found {
+		return nil, fmt.Errorf("%w: %s", errCharacterNotFound, name)
+	}
+
+	_, err = s.Queries.GetWatchedCharacter(ctx, sqlc.GetWatchedCharacterParams{UserID: userID, NameKey: nameKey(char.Name)})
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s", errWatchedCharacterExists, char.Name)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	err = s.Queries.CreateWatchedCharacter(ctx, sqlc.CreateWatchedCharacterParams{
+		UserID:             userID,
+		Name:               char.Name,
+		NameKey:            nameKey(char.Name),
+		World:              char.World,
+		NotificationEmails: notificationEmail,
+		LastChecked:        time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.Queries.GetWatchedCharacter(ctx, sqlc.GetWatchedCharacterParams{UserID: userID, NameKey: nameKey(char.Name)})
+	if err != nil {
+		return nil, err
+	}
+	watched := newWatchedCharacter(row)
+
+	return &watched, nil
+}
+
+func (s *WatchedCharacterService) Unwatch(ctx context.Context, userID int64, name string) error {
+	deleted, err := s.Queries.DeleteWatchedCharacter(ctx, sqlc.DeleteWatchedCharacterParams{UserID: userID, NameKey: nameKey(name)})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %s", errWatchedCharacterNotFound, name)
+	}
+
+	return nil
+}
+
+// CheckCharacter looks the watched character up by the name it was last seen
+// with. A character that was renamed is still found by its old name, as one
+// of its former names, and is from then on watched by its new name.
+//
+// Names are the only identity TibiaData has, so a rename is only noticed
+// while the old name is still reserved for the character. Passes run far
+// more often than former names expire, so that is not a concern in practice.
+func (p *Poller) CheckCharacter(ctx context.Context, watched sqlc.WatchedCharacter) (err error) {
+	ctx, span := tracer.Start(ctx, "Poller.CheckCharacter", trace.WithAttributes(attribute.String("tibia.name", watched.Name)))
+	defer func() { endSpan(span, err) }()
+	logger := logFromContext(ctx).With("check_id", newCorrelationID(), "character", watched.Name)
+	ctx = contextWithLogger(ctx, logger)
+	logger.Debug("checking character")
+	p.updateState(func(s *PollerState) { s.CurrentName = watched.Name })
+
+	char, err := p.Api.SearchCharacter(ctx, watched.Name)
+	if err != nil {
+		if isUpstreamError(err) {
+			logger.Warn("character check skipped, no answer from TibiaData", "err", err)
+		} else {
+			logger.Error("character check failed", "err", err)
+		}
+		p.updateState(func(s *PollerState) { s.LastError = err.Error() })
+		return err
+	}
+
+	oldName := watched.Name
+	watched.LastChecked = time.Now().UTC()
+	switch char.NameStatus() {
+	case available:
+		logger.Warn("watched character not found, it may have been deleted")
+	case unavailable:
+		watched.World = char.World
+	case expiring:
+		logger.Info("watched character was renamed", "new_name", char.Name)
+		watched.Name = char.Name
+		watched.NameKey = nameKey(char.Name)
+		watched.World = char.World
+		watched.LastRenamed = sql.NullTime{Time: watched.LastChecked, Valid: true}
+	default:
+		logger.Warn("character found holds neither the watched name nor has it as former name", "found", char.Name)
+	}
+
+	renamed := watched.Name != oldName
+	if renamed {
+		err = p.saveRename(ctx, watched, oldName)
+	} else {
+		err = p.Queries.UpdateWatchedCharacter(ctx, updateWatchedCharacterParams(watched))
+	}
+	if err != nil {
+		logger.Error("failed to save character", "err", err)
+		return err
+	}
+	if !renamed {
+		return nil
+	}
+
+	characterRenamesTotal.Inc()
+	if watched.NotificationEmails != "" {
+		emails := strings.Split(watched.NotificationEmails, ",")
+		p.deliver(ctx, emails, oldName, func(ctx context.Context) error {
+			return p.Email.NotifyUserCharacterRenamed(ctx, emails, oldName, watched.Name)
+		})
+	}
+
+	return nil
+}
+
+// saveRename records the rename of watched from oldName and saves its new
+// name in one transaction, so a failed save does not record the rename again
+// with every pass. When the user already watches the new name, both watch
+// the same character and the renames are merged into that one.
+func (p *Poller) saveRename(ctx context.Context, watched sqlc.WatchedCharacter, oldName string) error {
+	tx, err := p.Db.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := p.Queries.WithTx(tx)
+
+	err = q.CreateCharacterRename(ctx, sqlc.CreateCharacterRenameParams{
+		WatchedCharacterID: watched.ID,
+		UserID:             watched.UserID,
+		OldName:            oldName,
+		NewName:            watched.Name,
+		Created:            watched.LastChecked,
+	})
+	if err != nil {
+		return err
+	}
+
+	existing, err := q.GetWatchedCharacter(ctx, sqlc.GetWatchedCharacterParams{UserID: watched.UserID, NameKey: watched.NameKey})
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := q.UpdateWatchedCharacter(ctx, updateWatchedCharacterParams(watched)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	logFromContext(ctx).Info("character is already watched by its new name, merging", "new_name", watched.Name)
+	err = q.MoveCharacterRenames(ctx, sqlc.MoveCharacterRenamesParams{ToID: existing.ID, FromID: watched.ID})
+	if err != nil {
+		return err
+	}
+	if err := q.DeleteWatchedCharacterByID(ctx, watched.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func updateWatchedCharacterParams(watched sqlc.WatchedCharacter) sqlc.UpdateWatchedCharacterParams {
+	return sqlc.UpdateWatchedCharacterParams{
+		Name:        watched.Name,
+		NameKey:     watched.NameKey,
+		World:       watched.World,
+		LastChecked: watched.LastChecked,
+		LastRenamed: watched.LastRenamed,
+		ID:          watched.ID,
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"rustydoggobytes/tibiabuddy/sqlc"
+	"rustydoggobytes/tibiabuddy/tibiadata"
+	"testing"
+	"time"
+)
+
+// newWatchTestPoller returns a poller that looks characters up at
+// tibiaDataURL, and a user to watch them for.
+func newWatchTestPoller(t *testing.T, tibiaDataURL string) (*Poller, *WatchedCharacterService, int64) {
+	t.Helper()
+	db, err := RepositoryClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	config := defaultConfig()
+	config.TibiaDataURLs = []string{tibiaDataURL}
+	config.TibiaComMode = "off"
+	api, err := NewTibiaDataApi(&config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := NewAuthService(db.Db, nil, "").signUp("alice@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewPoller(db, api, nil), NewWatchedCharacterService(db, api), user.ID
+}
+
+func TestCheckCharacter(t *testing.T) {
+	fixtures, err := filepath.Abs("tibiadata/testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// watched are the names watched before the check, with the world
+		// they were last seen in.
+		watched [][2]string
+		// renames are names the character checked had before, oldest first.
+		renames []string
+		check   string
+		// want is each watched character after the check as its name, its
+		// former names and its world.
+		want []string
+	}{
+		{"same name", [][2]string{{"Bubble", "Premia"}}, nil, "Bubble", []string{
+			`Bubble [] Antica`,
+		}},
+		{"renamed", [][2]string{{"Old Bubble", "Antica"}}, []string{"Ancient Bubble"}, "Old Bubble", []string{
+			`Bubble ["Ancient Bubble" "Old Bubble"] Antica`,
+		}},
+		{"renamed to a name already watched", [][2]string{{"Bubble", "Antica"}, {"Old Bubble", "Antica"}}, []string{"Ancient Bubble"}, "Old Bubble", []string{
+			`Bubble ["Ancient Bubble" "Old Bubble"] Antica`,
+		}},
+		{"not found", [][2]string{{"Free Name", "Antica"}}, nil, "Free Name", []string{
+			`Free Name [] Antica`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p, s, userID := newWatchTestPoller(t, "file://"+fixtures)
+			lastChecked := time.Now().UTC().Add(-time.Hour)
+			for _, w := range tt.watched {
+				err := p.Queries.CreateWatchedCharacter(ctx, sqlc.CreateWatchedCharacterParams{
+					UserID:      userID,
+					Name:        w[0],
+					NameKey:     nameKey(w[0]),
+					World:       w[1],
+					LastChecked: lastChecked,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			watched, err := p.Queries.GetWatchedCharacter(ctx, sqlc.GetWatchedCharacterParams{UserID: userID, NameKey: nameKey(tt.check)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldName := tt.check
+			for i := len(tt.renames) - 1; i >= 0; i-- {
+				err := p.Queries.CreateCharacterRename(ctx, sqlc.CreateCharacterRenameParams{
+					WatchedCharacterID: watched.ID,
+					UserID:             userID,
+					OldName:            tt.renames[i],
+					NewName:            oldName,
+					Created:            lastChecked.Add(-time.Duration(len(tt.renames)-i) * time.Hour),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				oldName = tt.renames[i]
+			}
+
+			if err := p.CheckCharacter(ctx, watched); err != nil {
+				t.Fatal(err)
+			}
+
+			list, err := s.List(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, w := range list {
+				got = append(got, fmt.Sprintf("%s %q %s", w.Name, w.FormerNames, w.World))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("watched =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCharacterWithoutAnswer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	p, s, userID := newWatchTestPoller(t, srv.URL)
+	lastChecked := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	err := p.Queries.CreateWatchedCharacter(ctx, sqlc.CreateWatchedCharacterParams{
+		UserID:      userID,
+		Name:        "Old Bubble",
+		NameKey:     nameKey("Old Bubble"),
+		World:       "Antica",
+		LastChecked: lastChecked,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watched, err := p.Queries.GetWatchedCharacter(ctx, sqlc.GetWatchedCharacterParams{UserID: userID, NameKey: nameKey("Old Bubble")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CheckCharacter(ctx, watched); !errors.Is(err, tibiadata.ErrUpstream) {
+		t.Fatalf("err = %v, want %v", err, tibiadata.ErrUpstream)
+	}
+	list, err := s.List(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "Old Bubble" || !list[0].LastChecked.Equal(lastChecked) {
+		t.Errorf("watched = %+v, want it unchanged", list)
+	}
+}